@@ -0,0 +1,67 @@
+// Package boltstore implements a newznab.CheckpointStore on top of an embedded bbolt key-value database.
+package boltstore
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucket = []byte("checkpoints")
+
+// Store is a newznab.CheckpointStore backed by a bbolt database
+type Store struct {
+	db *bolt.DB
+}
+
+var _ newznab.CheckpointStore = (*Store)(nil)
+
+// Open opens or creates the database at path
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open checkpoint database")
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		db.Close() // nolint:errcheck
+		return nil, errors.Wrap(err, "failed to create checkpoint bucket")
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Load returns the checkpoint stored under key
+func (s *Store) Load(key string) (newznab.Checkpoint, error) {
+	var checkpoint newznab.Checkpoint
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucket).Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		return json.Unmarshal(data, &checkpoint)
+	})
+	return checkpoint, errors.Wrap(err, "failed to load checkpoint")
+}
+
+// Save stores the checkpoint under key
+func (s *Store) Save(key string, checkpoint newznab.Checkpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal checkpoint")
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(key), data)
+	})
+	return errors.Wrap(err, "failed to save checkpoint")
+}
@@ -0,0 +1,38 @@
+package boltstore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "boltstore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint:errcheck
+	path := filepath.Join(dir, "checkpoints.db")
+
+	store, err := Open(path)
+	require.NoError(t, err)
+
+	checkpoint, err := store.Load("missing")
+	require.NoError(t, err)
+	require.True(t, checkpoint.IsZero())
+
+	saved := newznab.Checkpoint{LastID: "guid-1", PubDate: time.Date(2017, 5, 4, 12, 0, 0, 0, time.UTC)}
+	require.NoError(t, store.Save("feed", saved))
+	require.NoError(t, store.Close())
+
+	store, err = Open(path)
+	require.NoError(t, err)
+	defer store.Close() // nolint:errcheck
+	checkpoint, err = store.Load("feed")
+	require.NoError(t, err)
+	require.Equal(t, saved.LastID, checkpoint.LastID)
+	require.True(t, saved.PubDate.Equal(checkpoint.PubDate))
+}
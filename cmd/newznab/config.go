@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/mrobinsn/go-newznab/config"
+	"github.com/pkg/errors"
+)
+
+// configNames are the config files looked for in the user config dir, in order
+var configNames = []string{"config.yaml", "config.yml", "config.toml", "config.json"}
+
+// loadIndexer reads the indexer to talk to from the config file, then applies the NEWZNAB_* environment variables on top.
+// The indexer named profile is used, or NEWZNAB_INDEXER, falling back to the enabled indexer with the highest priority.
+// Without an explicit path NEWZNAB_CONFIG is used, falling back to newznab/config.yaml, .yml, .toml or .json
+// in the user config dir.
+func loadIndexer(path string, profile string) (config.Indexer, error) {
+	var indexer config.Indexer
+	if profile == "" {
+		profile = os.Getenv("NEWZNAB_INDEXER")
+	}
+	if path == "" {
+		path = os.Getenv("NEWZNAB_CONFIG")
+	}
+	if path == "" {
+		path = defaultConfigPath()
+	}
+	if path != "" {
+		cfg, err := config.Load(path)
+		if err != nil {
+			return indexer, err
+		}
+		if indexer, err = selectIndexer(cfg, profile); err != nil {
+			return indexer, err
+		}
+	} else if profile != "" {
+		return indexer, errors.Errorf("no config file to find indexer %q in", profile)
+	}
+
+	if url := os.Getenv("NEWZNAB_URL"); url != "" {
+		indexer.URL = url
+	}
+	if apikey := os.Getenv("NEWZNAB_APIKEY"); apikey != "" {
+		indexer.APIKey = apikey
+	}
+	if raw := os.Getenv("NEWZNAB_USERID"); raw != "" {
+		userID, err := strconv.Atoi(raw)
+		if err != nil {
+			return indexer, errors.Wrap(err, "invalid NEWZNAB_USERID")
+		}
+		indexer.UserID = userID
+	}
+	if raw := os.Getenv("NEWZNAB_INSECURE"); raw != "" {
+		insecure, err := strconv.ParseBool(raw)
+		if err != nil {
+			return indexer, errors.Wrap(err, "invalid NEWZNAB_INSECURE")
+		}
+		indexer.TLS.Insecure = insecure
+	}
+	return indexer, nil
+}
+
+// selectIndexer returns the indexer named profile, or the enabled indexer with the highest priority
+func selectIndexer(cfg *config.Config, profile string) (config.Indexer, error) {
+	if profile != "" {
+		return cfg.Indexer(profile)
+	}
+	enabled := cfg.Enabled()
+	if len(enabled) == 0 {
+		return config.Indexer{}, errors.New("the config has no enabled indexer")
+	}
+	return enabled[0], nil
+}
+
+// defaultConfigPath returns the first config file found in the user config dir
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	for _, name := range configNames {
+		path := filepath.Join(dir, "newznab", name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
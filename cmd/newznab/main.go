@@ -0,0 +1,350 @@
+// Command newznab talks to a newznab or torznab indexer from the command line.
+//
+// Usage:
+//
+//	newznab [flags] <command> [command flags] [args]
+//
+// The indexer is picked by name with -indexer from a config file in the format of the config package,
+// and the NEWZNAB_URL, NEWZNAB_APIKEY, NEWZNAB_USERID and NEWZNAB_INSECURE environment variables are applied on top.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const usage = `Usage: newznab [flags] <command> [command flags] [args]
+
+Commands:
+  caps                 show the capabilities of the indexer
+  search               search with any parameters
+  tv                   search for TV episodes
+  movie                search for movies
+  rss                  show the latest releases
+  details <guid>       show the details of a release
+  comments <id>        show or post (-add) the comments of a release
+  get <id>             download the NZB of a release
+  user                 show your account, usage and limits
+  cart                 show the cart, or change it with -add <id> and -del <id>
+
+Flags:
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, "newznab:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("newznab", flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to the YAML, TOML or JSON config file")
+	profile := fs.String("indexer", "", "name of the indexer in the config, defaults to the one with the highest priority")
+	baseURL := fs.String("url", "", "indexer URL, overrides the config")
+	apikey := fs.String("apikey", "", "API key, overrides the config")
+	userID := fs.Int("userid", 0, "user ID for RSS feeds, overrides the config")
+	insecure := fs.Bool("insecure", false, "skip TLS certificate verification")
+	format := fs.String("format", formatTable, "output format: table, json or xml")
+	verbose := fs.Bool("v", false, "log debug output")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	if *verbose {
+		log.SetLevel(log.DebugLevel)
+	}
+	switch *format {
+	case formatTable, formatJSON, formatXML:
+	default:
+		return errors.Errorf("unknown format %q", *format)
+	}
+
+	indexer, err := loadIndexer(*configPath, *profile)
+	if err != nil {
+		return err
+	}
+	if *baseURL != "" {
+		indexer.URL = *baseURL
+	}
+	if *apikey != "" {
+		indexer.APIKey = *apikey
+	}
+	if *userID != 0 {
+		indexer.UserID = *userID
+	}
+	if *insecure {
+		indexer.TLS.Insecure = true
+	}
+	if indexer.URL == "" {
+		return errors.New("no indexer URL configured, use -url, NEWZNAB_URL or a config file")
+	}
+
+	client, err := indexer.Client()
+	if err != nil {
+		return err
+	}
+	out := printer{w: stdout, format: *format}
+	command, commandArgs := fs.Arg(0), fs.Args()[1:]
+	switch command {
+	case "caps":
+		return runCaps(client, out, commandArgs)
+	case "search", "tv", "movie":
+		return runSearch(client, out, command, commandArgs)
+	case "rss":
+		return runRSS(client, out, commandArgs)
+	case "details":
+		return runDetails(client, out, commandArgs)
+	case "comments":
+		return runComments(client, out, commandArgs)
+	case "get":
+		return runGet(client, stdout, commandArgs)
+	case "user":
+		return runUser(client, out, commandArgs)
+	case "cart":
+		return runCart(client, out, commandArgs)
+	}
+	return errors.Errorf("unknown command %q", command)
+}
+
+func runCaps(client newznab.Client, out printer, args []string) error {
+	fs := flag.NewFlagSet("caps", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	caps, err := client.Capabilities()
+	if err != nil {
+		return err
+	}
+	return out.caps(caps)
+}
+
+func runSearch(client newznab.Client, out printer, command string, args []string) error {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	var req newznab.SearchRequest
+	var categories categoriesFlag
+	params := paramsFlag{}
+	switch command {
+	case "search":
+		fs.StringVar(&req.Type, "type", "search", "search function: search, tvsearch, movie, music or book")
+	case "tv":
+		req.Type = "tvsearch"
+	case "movie":
+		req.Type = "movie"
+	}
+	fs.StringVar(&req.Query, "q", "", "search query")
+	fs.Var(&categories, "cat", "comma separated category ids")
+	if command != "movie" {
+		fs.IntVar(&req.TVRageID, "rid", 0, "TVRage id")
+		fs.IntVar(&req.TVDBID, "tvdbid", 0, "TheTVDB id")
+		fs.IntVar(&req.TVMazeID, "tvmazeid", 0, "TVMaze id")
+		fs.StringVar(&req.Season, "season", "", "season")
+		fs.StringVar(&req.Episode, "ep", "", "episode")
+	}
+	if command != "tv" {
+		fs.StringVar(&req.IMDBID, "imdbid", "", "IMDb id")
+		fs.IntVar(&req.IDs.TMDB, "tmdbid", 0, "TMDB id")
+	}
+	fs.IntVar(&req.Limit, "limit", 0, "maximum number of results")
+	fs.IntVar(&req.Offset, "offset", 0, "offset of the first result")
+	fs.BoolVar(&req.Extended, "extended", false, "request all attributes")
+	fs.Var(params, "param", "extra query parameter as key=value, can be repeated")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if req.Query == "" && fs.NArg() > 0 {
+		req.Query = strings.Join(fs.Args(), " ")
+	}
+	req.Categories = categories
+	req.Params = url.Values(params)
+
+	page, err := client.Search(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	for _, warning := range page.Warnings {
+		log.Warn(warning.Error())
+	}
+	return out.page(page)
+}
+
+func runRSS(client newznab.Client, out printer, args []string) error {
+	fs := flag.NewFlagSet("rss", flag.ContinueOnError)
+	var categories categoriesFlag
+	fs.Var(&categories, "cat", "comma separated category ids")
+	num := fs.Int("num", 50, "number of releases")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	nzbs, err := client.LoadRSSFeed(categories, *num)
+	if err != nil {
+		return err
+	}
+	return out.page(newznab.SearchPage{NZBs: nzbs})
+}
+
+func runDetails(client newznab.Client, out printer, args []string) error {
+	id, err := singleArg("details", args)
+	if err != nil {
+		return err
+	}
+	details, err := client.Details(id)
+	if err != nil {
+		return err
+	}
+	return out.details(details)
+}
+
+func runComments(client newznab.Client, out printer, args []string) error {
+	fs := flag.NewFlagSet("comments", flag.ContinueOnError)
+	offset := fs.Int("offset", 0, "number of comments to skip")
+	limit := fs.Int("limit", 0, "maximum number of comments")
+	add := fs.String("add", "", "post this comment instead of listing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	id, err := singleArg("comments", fs.Args())
+	if err != nil {
+		return err
+	}
+	nzb := newznab.NZB{ID: id}
+	if *add != "" {
+		comment, err := client.AddComment(context.Background(), nzb, *add)
+		if err != nil {
+			return err
+		}
+		return out.comments([]newznab.Comment{comment})
+	}
+	comments, err := client.Comments(context.Background(), nzb, *offset, *limit)
+	if err != nil {
+		return err
+	}
+	return out.comments(comments)
+}
+
+func runGet(client newznab.Client, stdout io.Writer, args []string) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	output := fs.String("o", "", "write the NZB to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	id, err := singleArg("get", fs.Args())
+	if err != nil {
+		return err
+	}
+	data, err := client.DownloadNZB(newznab.NZB{ID: id})
+	if err != nil {
+		return err
+	}
+	if *output != "" {
+		return errors.Wrap(ioutil.WriteFile(*output, data, 0644), "failed to write nzb")
+	}
+	_, err = stdout.Write(data)
+	return err
+}
+
+func runUser(client newznab.Client, out printer, args []string) error {
+	fs := flag.NewFlagSet("user", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	user, err := client.UserInfo(context.Background())
+	if err != nil {
+		return err
+	}
+	return out.user(user)
+}
+
+func runCart(client newznab.Client, out printer, args []string) error {
+	fs := flag.NewFlagSet("cart", flag.ContinueOnError)
+	add := fs.String("add", "", "add the release with this id to the cart")
+	del := fs.String("del", "", "remove the release with this id from the cart")
+	remove := fs.Bool("clear", false, "remove the listed releases from the cart")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if *add != "" {
+		if err := client.CartAdd(ctx, newznab.NZB{ID: *add}); err != nil {
+			return err
+		}
+	}
+	if *del != "" {
+		if err := client.CartDelete(ctx, newznab.NZB{ID: *del}); err != nil {
+			return err
+		}
+	}
+	nzbs, err := client.LoadCartFeed(ctx, *remove)
+	if err != nil {
+		return err
+	}
+	return out.page(newznab.SearchPage{NZBs: nzbs})
+}
+
+func singleArg(command string, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errors.Errorf("%s expects exactly one id", command)
+	}
+	return args[0], nil
+}
+
+// categoriesFlag parses a comma separated list of category ids
+type categoriesFlag []int
+
+func (c *categoriesFlag) String() string {
+	var ids []string
+	for _, id := range *c {
+		ids = append(ids, strconv.Itoa(id))
+	}
+	return strings.Join(ids, ",")
+}
+
+func (c *categoriesFlag) Set(value string) error {
+	for _, raw := range strings.Split(value, ",") {
+		if raw = strings.TrimSpace(raw); raw == "" {
+			continue
+		}
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			return errors.Errorf("invalid category %q", raw)
+		}
+		*c = append(*c, id)
+	}
+	return nil
+}
+
+// paramsFlag collects key=value query parameters
+type paramsFlag url.Values
+
+func (p paramsFlag) String() string {
+	return url.Values(p).Encode()
+}
+
+func (p paramsFlag) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return errors.Errorf("invalid parameter %q, expected key=value", value)
+	}
+	url.Values(p).Add(parts[0], parts[1])
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/mrobinsn/go-newznab/newznabtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	idx := newznabtest.NewIndexer()
+	defer idx.Close()
+	idx.AddNZBs(
+		newznab.NZB{
+			ID:       "show-1",
+			Title:    "Show.S01E02.720p.HDTV.x264-GRP",
+			Category: []string{"5040"},
+			TVDBID:   "1234",
+			Season:   "1",
+			Episode:  "2",
+			Size:     1536,
+			PubDate:  time.Date(2017, 5, 1, 0, 0, 0, 0, time.UTC),
+		},
+		newznab.NZB{
+			ID:       "movie-1",
+			Title:    "Movie.2016.1080p.BluRay.x264-GRP",
+			Category: []string{"2040"},
+			IMDBID:   "0123456",
+			PubDate:  time.Date(2017, 5, 2, 0, 0, 0, 0, time.UTC),
+		},
+	)
+	idx.AddComments("show-1", newznab.Comment{Title: "user", Content: "thanks", PubDate: time.Date(2017, 5, 4, 0, 0, 0, 0, time.UTC)})
+	idx.SetPayload("show-1", []byte("<nzb/>"))
+
+	t.Setenv("NEWZNAB_CONFIG", "")
+	t.Setenv("NEWZNAB_URL", idx.URL)
+	t.Setenv("NEWZNAB_APIKEY", idx.APIKey)
+	t.Setenv("NEWZNAB_USERID", "1")
+
+	exec := func(t *testing.T, args ...string) string {
+		var out bytes.Buffer
+		require.NoError(t, run(args, &out))
+		return out.String()
+	}
+
+	t.Run("search table", func(t *testing.T) {
+		out := exec(t, "search", "-cat", "5000", "show")
+		require.Contains(t, out, "Show.S01E02.720p.HDTV.x264-GRP")
+		require.Contains(t, out, "1.5 KiB")
+		require.NotContains(t, out, "Movie")
+		idx.RequireLastQuery(t, map[string]string{"t": "search", "q": "show", "cat": "5000"})
+	})
+
+	t.Run("tv json", func(t *testing.T) {
+		out := exec(t, "-format", "json", "tv", "-tvdbid", "1234", "-season", "1", "-ep", "2")
+		var nzbs []newznab.NZB
+		require.NoError(t, json.Unmarshal([]byte(out), &nzbs))
+		require.Len(t, nzbs, 1)
+		require.Equal(t, "show-1", nzbs[0].ID)
+		idx.RequireLastQuery(t, map[string]string{"t": "tvsearch", "tvdbid": "1234", "season": "1", "ep": "2"})
+	})
+
+	t.Run("movie xml", func(t *testing.T) {
+		out := exec(t, "-format", "xml", "movie", "-imdbid", "0123456")
+		require.True(t, strings.HasPrefix(out, "<?xml"))
+		require.Contains(t, out, "Movie.2016.1080p.BluRay.x264-GRP")
+	})
+
+	t.Run("extra params", func(t *testing.T) {
+		exec(t, "search", "-param", "attrs=poster,group", "-limit", "5", "show")
+		idx.RequireLastQuery(t, map[string]string{"attrs": "poster,group", "limit": "5"})
+	})
+
+	t.Run("rss", func(t *testing.T) {
+		out := exec(t, "rss", "-cat", "2040", "-num", "10")
+		require.Contains(t, out, "Movie.2016")
+		require.Equal(t, "/rss", idx.LastRequest().Path)
+	})
+
+	t.Run("caps", func(t *testing.T) {
+		out := exec(t, "caps")
+		require.Contains(t, out, "tvsearch")
+		require.Contains(t, out, "q,rid,tvdbid,tvmazeid,season,ep")
+	})
+
+	t.Run("details and comments", func(t *testing.T) {
+		require.Contains(t, exec(t, "details", "show-1"), "Show.S01E02")
+		require.Contains(t, exec(t, "comments", "show-1"), "thanks")
+		require.Contains(t, exec(t, "comments", "-add", "nice", "show-1"), "show-1-comment-2")
+		require.NotContains(t, exec(t, "comments", "-offset", "1", "show-1"), "thanks")
+	})
+
+	t.Run("user", func(t *testing.T) {
+		out := exec(t, "user")
+		require.Contains(t, out, "user-1")
+		require.Contains(t, out, "api requests")
+	})
+
+	t.Run("cart", func(t *testing.T) {
+		require.Contains(t, exec(t, "cart", "-add", "show-1"), "Show.S01E02")
+		require.Contains(t, exec(t, "cart", "-clear"), "Show.S01E02")
+		require.NotContains(t, exec(t, "cart"), "Show.S01E02")
+	})
+
+	t.Run("get", func(t *testing.T) {
+		require.Equal(t, "<nzb/>", exec(t, "get", "show-1"))
+
+		path := filepath.Join(t.TempDir(), "show.nzb")
+		exec(t, "get", "-o", path, "show-1")
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "<nzb/>", string(data))
+	})
+
+	t.Run("config file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "indexers.yaml")
+		require.NoError(t, ioutil.WriteFile(path, []byte(`indexers:
+  - name: main
+    url: `+idx.URL+`
+    apikey: wrong
+    api_path: /v1/api
+    priority: 10
+  - name: movies
+    url: `+idx.URL+`
+    apikey: `+idx.APIKey+`
+    categories: [2040]
+`), 0644))
+		t.Setenv("NEWZNAB_URL", "")
+		t.Setenv("NEWZNAB_APIKEY", "")
+
+		err := run([]string{"-config", path, "search", "show"}, ioutil.Discard)
+		require.Error(t, err, "the indexer with the highest priority is used")
+		require.Contains(t, err.Error(), "100")
+
+		require.NoError(t, run([]string{"-config", path, "-apikey", idx.APIKey, "search", "show"}, ioutil.Discard))
+		require.Equal(t, "/v1/api", idx.LastRequest().Path)
+
+		out := exec(t, "-config", path, "-indexer", "movies", "search")
+		require.Contains(t, out, "Movie.2016")
+		require.NotContains(t, out, "Show.S01E02")
+		idx.RequireLastQuery(t, map[string]string{"cat": "2040"})
+
+		t.Setenv("NEWZNAB_INDEXER", "movies")
+		exec(t, "-config", path, "rss")
+		idx.RequireLastQuery(t, map[string]string{"t": "2040"})
+
+		require.Error(t, run([]string{"-config", path, "-indexer", "missing", "caps"}, ioutil.Discard))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		require.Error(t, run([]string{"unknown"}, ioutil.Discard))
+		require.Error(t, run([]string{"-format", "yaml", "caps"}, ioutil.Discard))
+		require.Error(t, run([]string{"details"}, ioutil.Discard))
+		require.Error(t, run([]string{"search", "-cat", "tv"}, ioutil.Discard))
+	})
+}
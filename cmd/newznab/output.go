@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/mrobinsn/go-newznab/server"
+	"github.com/pkg/errors"
+)
+
+// Output formats
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatXML   = "xml"
+)
+
+// printer writes command results in the selected format
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func (p printer) page(page newznab.SearchPage) error {
+	switch p.format {
+	case formatJSON:
+		return p.json(page.NZBs)
+	case formatXML:
+		return server.WriteFeed(p.w, server.NewFeed("newznab", "", page.NZBs, page.Offset, page.Total))
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTITLE\tSIZE\tCATEGORY\tPUBLISHED\tGRABS")
+	for _, nzb := range page.NZBs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\n",
+			nzb.ID, nzb.Title, formatSize(nzb.Size), strings.Join(nzb.Category, ","), formatDate(nzb.PubDate), nzb.NumGrabs)
+	}
+	if page.Total > 0 {
+		fmt.Fprintf(tw, "\n%d-%d of %d\n", page.Offset+1, page.Offset+len(page.NZBs), page.Total)
+	}
+	return tw.Flush()
+}
+
+func (p printer) caps(caps newznab.Capabilities) error {
+	switch p.format {
+	case formatJSON:
+		return p.json(caps)
+	case formatXML:
+		return server.WriteCapabilities(p.w, caps)
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Server:\t%s\n\n", caps.Server.Title)
+	fmt.Fprintln(tw, "SEARCH\tAVAILABLE\tPARAMS")
+	fmt.Fprintf(tw, "search\t%s\t%s\n", caps.Searching.Search.Available, caps.Searching.Search.SupportedParams)
+	fmt.Fprintf(tw, "tvsearch\t%s\t%s\n", caps.Searching.TvSearch.Available, caps.Searching.TvSearch.SupportedParams)
+	fmt.Fprintf(tw, "movie\t%s\t%s\n", caps.Searching.MovieSearch.Available, caps.Searching.MovieSearch.SupportedParams)
+	fmt.Fprintln(tw, "\nCATEGORY\tNAME")
+	for _, cat := range caps.Categories.Category {
+		fmt.Fprintf(tw, "%s\t%s\n", cat.ID, cat.Name)
+		for _, sub := range cat.Subcat {
+			fmt.Fprintf(tw, "%s\t  %s\n", sub.ID, sub.Name)
+		}
+	}
+	return tw.Flush()
+}
+
+func (p printer) details(details newznab.Details) error {
+	switch p.format {
+	case formatJSON:
+		return p.json(details)
+	case formatXML:
+		return p.xml(details)
+	}
+	item := details.Channel.Item
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "title\t%s\n", item.Title)
+	fmt.Fprintf(tw, "guid\t%s\n", item.Guid.Text)
+	fmt.Fprintf(tw, "link\t%s\n", item.Link)
+	fmt.Fprintf(tw, "published\t%s\n", item.PubDate)
+	fmt.Fprintf(tw, "category\t%s\n", item.Category)
+	for _, attr := range item.Attr {
+		fmt.Fprintf(tw, "%s\t%s\n", attr.Name, attr.Value)
+	}
+	return tw.Flush()
+}
+
+func (p printer) user(user newznab.UserInfo) error {
+	switch p.format {
+	case formatJSON:
+		return p.json(user)
+	case formatXML:
+		return server.WriteUser(p.w, user)
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "username\t%s\n", user.Username)
+	fmt.Fprintf(tw, "role\t%s\n", user.Role)
+	fmt.Fprintf(tw, "api requests\t%s\n", formatUsage(user.APIRequests, user.APILimit))
+	fmt.Fprintf(tw, "downloads\t%s\n", formatUsage(user.DownloadRequests, user.DownloadLimit))
+	fmt.Fprintf(tw, "grabs\t%d\n", user.Grabs)
+	fmt.Fprintf(tw, "expires\t%s\n", formatDate(user.Expires))
+	return tw.Flush()
+}
+
+func (p printer) comments(comments []newznab.Comment) error {
+	switch p.format {
+	case formatJSON:
+		return p.json(comments)
+	case formatXML:
+		return server.WriteComments(p.w, comments)
+	}
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tPUBLISHED\tAUTHOR\tCOMMENT")
+	for _, comment := range comments {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", comment.ID, formatDate(comment.PubDate), comment.Author, comment.Content)
+	}
+	return tw.Flush()
+}
+
+func (p printer) json(v interface{}) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return errors.Wrap(enc.Encode(v), "failed to encode json")
+}
+
+func (p printer) xml(v interface{}) error {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode xml")
+	}
+	_, err = fmt.Fprintf(p.w, "%s%s\n", xml.Header, data)
+	return err
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+func formatUsage(used int, limit int) string {
+	if limit == 0 {
+		return strconv.Itoa(used)
+	}
+	return fmt.Sprintf("%d / %d", used, limit)
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
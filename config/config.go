@@ -0,0 +1,332 @@
+// Package config loads indexer definitions from a YAML, TOML or JSON file and builds ready to use clients from them.
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported file formats
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// Config is a set of indexers
+type Config struct {
+	Indexers []Indexer `json:"indexers" yaml:"indexers" toml:"indexers"`
+}
+
+// Indexer describes a single newznab or torznab indexer
+type Indexer struct {
+	Name string `json:"name" yaml:"name" toml:"name"`
+	URL  string `json:"url" yaml:"url" toml:"url"`
+	// APIPath and RSSPath override the default /api and /rss paths, see newznab.Client.WithAPIPath
+	APIPath string `json:"api_path,omitempty" yaml:"api_path,omitempty" toml:"api_path,omitempty"`
+	RSSPath string `json:"rss_path,omitempty" yaml:"rss_path,omitempty" toml:"rss_path,omitempty"`
+	// Format is the response format of searches, "xml" or "json", see newznab.Client.WithResponseFormat
+	Format string `json:"format,omitempty" yaml:"format,omitempty" toml:"format,omitempty"`
+	// APIKey is the key itself, "env:NAME" to read it from an environment variable or "file:/path" to read it from a file
+	APIKey string `json:"apikey" yaml:"apikey" toml:"apikey"`
+	UserID int    `json:"user_id,omitempty" yaml:"user_id,omitempty" toml:"user_id,omitempty"`
+	TLS    TLS    `json:"tls,omitempty" yaml:"tls,omitempty" toml:"tls,omitempty"`
+	// Priority orders indexers, higher values are preferred
+	Priority int `json:"priority,omitempty" yaml:"priority,omitempty" toml:"priority,omitempty"`
+	// Categories are searched when a request doesn't ask for any, see newznab.Client.WithDefaultCategories
+	Categories []int     `json:"categories,omitempty" yaml:"categories,omitempty" toml:"categories,omitempty"`
+	RateLimit  RateLimit `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty" toml:"rate_limit,omitempty"`
+	// Enabled defaults to true
+	Enabled *bool `json:"enabled,omitempty" yaml:"enabled,omitempty" toml:"enabled,omitempty"`
+}
+
+// TLS configures the connection to an indexer
+type TLS struct {
+	// Insecure skips certificate verification
+	Insecure bool `json:"insecure,omitempty" yaml:"insecure,omitempty" toml:"insecure,omitempty"`
+	// CAFile is a PEM file with additional certificate authorities to trust
+	CAFile string `json:"ca_file,omitempty" yaml:"ca_file,omitempty" toml:"ca_file,omitempty"`
+	// ServerName overrides the name used to verify the certificate
+	ServerName string `json:"server_name,omitempty" yaml:"server_name,omitempty" toml:"server_name,omitempty"`
+}
+
+// RateLimit allows at most Requests requests every Interval, it is disabled when Requests is zero
+type RateLimit struct {
+	Requests int      `json:"requests,omitempty" yaml:"requests,omitempty" toml:"requests,omitempty"`
+	Interval Duration `json:"interval,omitempty" yaml:"interval,omitempty" toml:"interval,omitempty"`
+}
+
+// Duration is a time.Duration written as a string like "1m30s"
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return errors.Wrapf(err, "invalid duration %q", text)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Load reads the config file at path, the format is picked by its extension.
+// Secrets are resolved and the config is validated.
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read config")
+	}
+	var format string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		format = FormatJSON
+	case ".yaml", ".yml":
+		format = FormatYAML
+	case ".toml":
+		format = FormatTOML
+	default:
+		return nil, errors.Errorf("unknown config format for %s", path)
+	}
+	cfg, err := Parse(data, format)
+	return cfg, errors.Wrapf(err, "invalid config %s", path)
+}
+
+// Parse reads a config in the given format, resolves secrets and validates it
+func Parse(data []byte, format string) (*Config, error) {
+	var cfg Config
+	switch format {
+	case FormatJSON:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&cfg); err != nil {
+			return nil, errors.Wrap(err, "failed to parse json")
+		}
+	case FormatYAML:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil {
+			return nil, errors.Wrap(err, "failed to parse yaml")
+		}
+	case FormatTOML:
+		meta, err := toml.Decode(string(data), &cfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse toml")
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return nil, errors.Errorf("unknown field %s", undecoded[0])
+		}
+	default:
+		return nil, errors.Errorf("unknown config format %q", format)
+	}
+
+	for i := range cfg.Indexers {
+		key, err := resolveSecret(cfg.Indexers[i].APIKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "indexer %q", cfg.Indexers[i].Name)
+		}
+		cfg.Indexers[i].APIKey = key
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks every indexer definition
+func (c *Config) Validate() error {
+	names := map[string]bool{}
+	for i, indexer := range c.Indexers {
+		if indexer.Name == "" {
+			return errors.Errorf("indexer %d has no name", i+1)
+		}
+		if names[indexer.Name] {
+			return errors.Errorf("indexer %q is defined more than once", indexer.Name)
+		}
+		names[indexer.Name] = true
+		if err := indexer.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks this indexer definition
+func (i Indexer) Validate() error {
+	parsed, err := url.Parse(i.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.Errorf("indexer %q has an invalid url %q", i.Name, i.URL)
+	}
+	for _, path := range []string{i.APIPath, i.RSSPath} {
+		if _, err := url.Parse(path); err != nil {
+			return errors.Errorf("indexer %q has an invalid path %q", i.Name, path)
+		}
+	}
+	switch newznab.ResponseFormat(i.Format) {
+	case "", newznab.FormatXML, newznab.FormatJSON:
+	default:
+		return errors.Errorf("indexer %q has an unknown format %q", i.Name, i.Format)
+	}
+	if i.IsEnabled() && i.APIKey == "" {
+		return errors.Errorf("indexer %q has no api key", i.Name)
+	}
+	if i.UserID < 0 || i.Priority < 0 {
+		return errors.Errorf("indexer %q has a negative user id or priority", i.Name)
+	}
+	for _, category := range i.Categories {
+		if category <= 0 {
+			return errors.Errorf("indexer %q has an invalid category %d", i.Name, category)
+		}
+	}
+	if i.RateLimit.Requests < 0 || (i.RateLimit.Requests > 0 && i.RateLimit.Interval <= 0) {
+		return errors.Errorf("indexer %q needs a positive rate limit interval", i.Name)
+	}
+	return nil
+}
+
+// IsEnabled reports whether the indexer should be used
+func (i Indexer) IsEnabled() bool {
+	return i.Enabled == nil || *i.Enabled
+}
+
+// Enabled returns the enabled indexers, highest priority first
+func (c *Config) Enabled() []Indexer {
+	var enabled []Indexer
+	for _, indexer := range c.Indexers {
+		if indexer.IsEnabled() {
+			enabled = append(enabled, indexer)
+		}
+	}
+	sort.SliceStable(enabled, func(a, b int) bool {
+		return enabled[a].Priority > enabled[b].Priority
+	})
+	return enabled
+}
+
+// Indexer returns the indexer with the given name
+func (c *Config) Indexer(name string) (Indexer, error) {
+	for _, indexer := range c.Indexers {
+		if indexer.Name == name {
+			return indexer, nil
+		}
+	}
+	return Indexer{}, errors.Errorf("no indexer named %q", name)
+}
+
+// Priority returns the names of the enabled indexers from most to least preferred,
+// for use as newznab.DedupeOptions.IndexerPriority
+func (c *Config) Priority() []string {
+	var names []string
+	for _, indexer := range c.Enabled() {
+		names = append(names, indexer.Name)
+	}
+	return names
+}
+
+// Clients returns a client for every enabled indexer by name
+func (c *Config) Clients() (map[string]newznab.Client, error) {
+	clients := map[string]newznab.Client{}
+	for _, indexer := range c.Enabled() {
+		client, err := indexer.Client()
+		if err != nil {
+			return nil, err
+		}
+		clients[indexer.Name] = client
+	}
+	return clients, nil
+}
+
+// Aggregator returns an aggregator searching every enabled indexer, highest priority first
+func (c *Config) Aggregator(timeout time.Duration) (*newznab.Aggregator, error) {
+	var indexers []newznab.Indexer
+	for _, indexer := range c.Enabled() {
+		client, err := indexer.Client()
+		if err != nil {
+			return nil, err
+		}
+		indexers = append(indexers, newznab.Indexer{Name: indexer.Name, Client: client})
+	}
+	return newznab.NewAggregator(timeout, indexers...), nil
+}
+
+// Client returns a client for this indexer
+func (i Indexer) Client() (newznab.Client, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: i.TLS.Insecure, // nolint:gosec
+		ServerName:         i.TLS.ServerName,
+	}
+	if i.TLS.CAFile != "" {
+		pem, err := ioutil.ReadFile(i.TLS.CAFile)
+		if err != nil {
+			return newznab.Client{}, errors.Wrapf(err, "indexer %q: failed to read ca file", i.Name)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return newznab.Client{}, errors.Errorf("indexer %q: no certificates found in %s", i.Name, i.TLS.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	var rt http.RoundTripper = transport
+	if i.RateLimit.Requests > 0 {
+		rt = newRateLimiter(rt, i.RateLimit.Requests, time.Duration(i.RateLimit.Interval))
+	}
+	client := newznab.New(i.URL, i.APIKey, i.UserID, false).WithTransport(rt)
+	if i.APIPath != "" {
+		client = client.WithAPIPath(i.APIPath)
+	}
+	if i.RSSPath != "" {
+		client = client.WithRSSPath(i.RSSPath)
+	}
+	if i.Format != "" {
+		client = client.WithResponseFormat(newznab.ResponseFormat(i.Format))
+	}
+	if len(i.Categories) > 0 {
+		client = client.WithDefaultCategories(i.Categories)
+	}
+	return client, nil
+}
+
+// resolveSecret reads "env:NAME" and "file:/path" references, other values are returned as is
+func resolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "env:"):
+		name := strings.TrimPrefix(value, "env:")
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", errors.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+	case strings.HasPrefix(value, "file:"):
+		data, err := ioutil.ReadFile(strings.TrimPrefix(value, "file:"))
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read secret")
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return value, nil
+}
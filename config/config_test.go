@@ -0,0 +1,212 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/mrobinsn/go-newznab/newznabtest"
+	"github.com/stretchr/testify/require"
+)
+
+const yamlConfig = `
+indexers:
+  - name: primary
+    url: https://primary.example.com
+    apikey: env:PRIMARY_APIKEY
+    user_id: 12
+    priority: 10
+    categories: [5040, 5045]
+    rate_limit:
+      requests: 5
+      interval: 1m
+  - name: backup
+    url: https://backup.example.com
+    api_path: /api/v1/api
+    apikey: backup-key
+    tls:
+      insecure: true
+  - name: disabled
+    url: https://disabled.example.com
+    enabled: false
+`
+
+const tomlConfig = `
+[[indexers]]
+name = "primary"
+url = "https://primary.example.com"
+apikey = "primary-key"
+priority = 10
+
+[indexers.rate_limit]
+requests = 5
+interval = "1m"
+`
+
+const jsonConfig = `{
+  "indexers": [
+    {"name": "primary", "url": "https://primary.example.com", "apikey": "primary-key", "categories": [2000]}
+  ]
+}`
+
+func TestParse(t *testing.T) {
+	t.Run("yaml", func(t *testing.T) {
+		t.Setenv("PRIMARY_APIKEY", "primary-key")
+		cfg, err := Parse([]byte(yamlConfig), FormatYAML)
+		require.NoError(t, err)
+		require.Len(t, cfg.Indexers, 3)
+
+		primary, err := cfg.Indexer("primary")
+		require.NoError(t, err)
+		require.Equal(t, "primary-key", primary.APIKey)
+		require.Equal(t, 12, primary.UserID)
+		require.Equal(t, []int{5040, 5045}, primary.Categories)
+		require.Equal(t, 5, primary.RateLimit.Requests)
+		require.Equal(t, Duration(time.Minute), primary.RateLimit.Interval)
+
+		backup, err := cfg.Indexer("backup")
+		require.NoError(t, err)
+		require.Equal(t, "/api/v1/api", backup.APIPath)
+		require.True(t, backup.TLS.Insecure)
+
+		require.Equal(t, []string{"primary", "backup"}, cfg.Priority())
+		_, err = cfg.Indexer("missing")
+		require.Error(t, err)
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		cfg, err := Parse([]byte(tomlConfig), FormatTOML)
+		require.NoError(t, err)
+		require.Len(t, cfg.Indexers, 1)
+		require.Equal(t, Duration(time.Minute), cfg.Indexers[0].RateLimit.Interval)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		cfg, err := Parse([]byte(jsonConfig), FormatJSON)
+		require.NoError(t, err)
+		require.Equal(t, []int{2000}, cfg.Indexers[0].Categories)
+	})
+
+	t.Run("secret from file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "key")
+		require.NoError(t, ioutil.WriteFile(path, []byte("file-key\n"), 0600))
+		cfg, err := Parse([]byte(`{"indexers": [{"name": "a", "url": "https://a.example.com", "apikey": "file:`+path+`"}]}`), FormatJSON)
+		require.NoError(t, err)
+		require.Equal(t, "file-key", cfg.Indexers[0].APIKey)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for name, config := range map[string]string{
+			"missing env":    `{"indexers": [{"name": "a", "url": "https://a.example.com", "apikey": "env:GO_NEWZNAB_UNSET"}]}`,
+			"no name":        `{"indexers": [{"url": "https://a.example.com", "apikey": "key"}]}`,
+			"duplicate name": `{"indexers": [{"name": "a", "url": "https://a.example.com", "apikey": "key"}, {"name": "a", "url": "https://b.example.com", "apikey": "key"}]}`,
+			"bad url":        `{"indexers": [{"name": "a", "url": "a.example.com", "apikey": "key"}]}`,
+			"no key":         `{"indexers": [{"name": "a", "url": "https://a.example.com"}]}`,
+			"api path":       `{"indexers": [{"name": "a", "url": "https://a.example.com", "apikey": "key", "api_path": "%zz"}]}`,
+			"format":         `{"indexers": [{"name": "a", "url": "https://a.example.com", "apikey": "key", "format": "csv"}]}`,
+			"rate limit":     `{"indexers": [{"name": "a", "url": "https://a.example.com", "apikey": "key", "rate_limit": {"requests": 1}}]}`,
+			"duration":       `{"indexers": [{"name": "a", "url": "https://a.example.com", "apikey": "key", "rate_limit": {"requests": 1, "interval": "soon"}}]}`,
+			"unknown field":  `{"indexers": [{"name": "a", "url": "https://a.example.com", "apikey": "key", "api_key": "key"}]}`,
+		} {
+			_, err := Parse([]byte(config), FormatJSON)
+			require.Error(t, err, name)
+		}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "indexers.toml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(tomlConfig), 0644))
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Indexers, 1)
+
+	path = filepath.Join(dir, "indexers.ini")
+	require.NoError(t, ioutil.WriteFile(path, []byte(tomlConfig), 0644))
+	_, err = Load(path)
+	require.Error(t, err)
+}
+
+func TestClients(t *testing.T) {
+	primary := newznabtest.NewIndexer()
+	defer primary.Close()
+	primary.AddNZBs(newznab.NZB{ID: "primary-1", Title: "Show.S01E02.720p.HDTV.x264-GRP"})
+	backup := newznabtest.NewIndexer()
+	defer backup.Close()
+	backup.AddNZBs(newznab.NZB{ID: "backup-1", Title: "Show.S01E02.1080p.WEB-DL.x264-GRP"})
+
+	cfg := &Config{Indexers: []Indexer{
+		{Name: "backup", URL: backup.URL, APIPath: "/custom/api", RSSPath: "/custom/rss", APIKey: backup.APIKey, UserID: backup.UserID},
+		{Name: "primary", URL: primary.URL, APIKey: primary.APIKey, Priority: 5, RateLimit: RateLimit{Requests: 2, Interval: Duration(100 * time.Millisecond)}},
+	}}
+	require.NoError(t, cfg.Validate())
+
+	t.Run("clients", func(t *testing.T) {
+		clients, err := cfg.Clients()
+		require.NoError(t, err)
+		require.Len(t, clients, 2)
+
+		results, err := clients["backup"].SearchWithQuery(nil, "show", "search")
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, "/custom/api", backup.LastRequest().Path)
+
+		_, err = clients["backup"].LoadRSSFeed(nil, 10)
+		require.NoError(t, err)
+		require.Equal(t, "/custom/rss", backup.LastRequest().Path)
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		client, err := cfg.Indexers[1].Client()
+		require.NoError(t, err)
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			_, err := client.SearchWithQuery(nil, "show", "search")
+			require.NoError(t, err)
+		}
+		require.True(t, time.Since(start) >= 100*time.Millisecond, "three requests at two per 100ms take at least 100ms")
+	})
+
+	t.Run("canceled requests keep their slot", func(t *testing.T) {
+		limiter := newRateLimiter(http.DefaultTransport, 1, time.Hour)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, primary.URL, nil)
+		require.NoError(t, err)
+		_, err = limiter.RoundTrip(req) // nolint:bodyclose
+		require.Equal(t, context.Canceled, err)
+		require.True(t, limiter.next.IsZero(), "no slot was taken")
+	})
+
+	t.Run("default categories", func(t *testing.T) {
+		indexer := cfg.Indexers[0]
+		indexer.Categories = []int{5040, 5045}
+		client, err := indexer.Client()
+		require.NoError(t, err)
+
+		_, err = client.SearchWithQuery(nil, "show", "search")
+		require.NoError(t, err)
+		backup.RequireLastQuery(t, map[string]string{"cat": "5040,5045"})
+		_, err = client.Search(context.Background(), newznab.SearchRequest{Query: "show"})
+		require.NoError(t, err)
+		backup.RequireLastQuery(t, map[string]string{"cat": "5040,5045"})
+		_, err = client.SearchWithQuery([]int{2000}, "show", "search")
+		require.NoError(t, err)
+		backup.RequireLastQuery(t, map[string]string{"cat": "2000"})
+	})
+
+	t.Run("aggregator", func(t *testing.T) {
+		agg, err := cfg.Aggregator(time.Second)
+		require.NoError(t, err)
+		require.Equal(t, "primary", agg.Indexers()[0].Name)
+
+		res := agg.SearchWithQuery(context.Background(), nil, "show", "search")
+		require.Empty(t, res.Failed())
+		require.Len(t, res.NZBs, 2)
+	})
+}
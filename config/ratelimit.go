@@ -0,0 +1,49 @@
+package config
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rateLimiter is an http.RoundTripper that spaces requests out evenly to stay within a rate limit
+type rateLimiter struct {
+	transport http.RoundTripper
+	spacing   time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func newRateLimiter(transport http.RoundTripper, requests int, interval time.Duration) *rateLimiter {
+	return &rateLimiter{
+		transport: transport,
+		spacing:   interval / time.Duration(requests),
+	}
+}
+
+func (r *rateLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A canceled request must not take a slot from the requests after it
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	now := time.Now()
+	slot := r.next
+	if slot.Before(now) {
+		slot = now
+	}
+	r.next = slot.Add(r.spacing)
+	r.mu.Unlock()
+
+	if wait := time.Until(slot); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	return r.transport.RoundTrip(req)
+}
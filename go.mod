@@ -1,8 +1,19 @@
 module github.com/mrobinsn/go-newznab
 
+go 1.21
+
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/pkg/errors v0.8.1
 	github.com/sirupsen/logrus v1.4.1
 	github.com/stretchr/testify v1.3.0
-	golang.org/x/sys v0.0.0-20190405154228-4b34438f7a67 // indirect
+	go.etcd.io/bbolt v1.3.6
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.0.0-20200923182605-d9f96fdee20d // indirect
 )
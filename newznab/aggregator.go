@@ -0,0 +1,275 @@
+package newznab
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// IndexerStatus describes how a single indexer fared in an aggregated request
+type IndexerStatus string
+
+// Possible outcomes for an indexer taking part in an aggregated request
+const (
+	// StatusOK means the indexer answered successfully
+	StatusOK IndexerStatus = "ok"
+	// StatusError means the indexer answered with an error
+	StatusError IndexerStatus = "error"
+	// StatusTimeout means the indexer did not answer within the timeout
+	StatusTimeout IndexerStatus = "timeout"
+	// StatusCanceled means the request was canceled before the indexer answered
+	StatusCanceled IndexerStatus = "canceled"
+	// StatusSkipped means the indexer was not queried because its capabilities do not support the request
+	StatusSkipped IndexerStatus = "skipped"
+)
+
+// Indexer is a named Client taking part in an Aggregator
+type Indexer struct {
+	Name   string
+	Client Client
+	// Capabilities are used to skip indexers that cannot serve a request. When nil the indexer is always queried.
+	Capabilities *Capabilities
+}
+
+// IndexerReport is the outcome of an aggregated request for a single indexer
+type IndexerReport struct {
+	Name     string        `json:"name"`
+	Status   IndexerStatus `json:"status"`
+	Err      error         `json:"-"`
+	Results  int           `json:"results"`
+	Duration time.Duration `json:"duration"`
+}
+
+// AggregatedResults holds the merged results of an aggregated search and a report for every indexer
+type AggregatedResults struct {
+	NZBs    []NZB           `json:"nzbs"`
+	Reports []IndexerReport `json:"reports"`
+}
+
+// Failed returns the reports of all indexers that errored, timed out or were canceled
+func (r AggregatedResults) Failed() []IndexerReport {
+	var failed []IndexerReport
+	for _, report := range r.Reports {
+		if report.Status == StatusError || report.Status == StatusTimeout || report.Status == StatusCanceled {
+			failed = append(failed, report)
+		}
+	}
+	return failed
+}
+
+// SearchFunc runs a search against a single indexer, its requests must be made with ctx so they
+// are canceled with the aggregated request or when the indexer times out
+type SearchFunc func(ctx context.Context, c Client) ([]NZB, error)
+
+// CapabilityCheck reports whether an indexer with the given capabilities can serve a request
+type CapabilityCheck func(caps Capabilities) bool
+
+// Aggregator runs requests against several indexers concurrently and merges their results
+type Aggregator struct {
+	mu       sync.RWMutex
+	indexers []Indexer
+	timeout  time.Duration
+}
+
+// NewAggregator returns a new Aggregator for the given indexers.
+// Every indexer is given at most timeout to answer, a zero timeout disables the limit.
+func NewAggregator(timeout time.Duration, indexers ...Indexer) *Aggregator {
+	return &Aggregator{
+		indexers: indexers,
+		timeout:  timeout,
+	}
+}
+
+// Indexers returns a copy of the indexers of this aggregator
+func (a *Aggregator) Indexers() []Indexer {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return append([]Indexer(nil), a.indexers...)
+}
+
+// LoadCapabilities fetches the capabilities of every indexer so later searches can skip unsupported indexers
+func (a *Aggregator) LoadCapabilities(ctx context.Context) []IndexerReport {
+	reports := a.run(ctx, nil, func(ctx context.Context, i int, indexer Indexer) ([]NZB, error) {
+		caps, err := indexer.Client.caps(ctx, url.Values{"t": []string{"caps"}})
+		if err != nil {
+			return nil, err
+		}
+		a.mu.Lock()
+		a.indexers[i].Capabilities = &caps
+		a.mu.Unlock()
+		return nil, nil
+	})
+	return reports.Reports
+}
+
+// Search runs fn against every indexer for which check succeeds and merges the results.
+// A nil check queries every indexer. Failing indexers are reported but never fail the whole search.
+func (a *Aggregator) Search(ctx context.Context, check CapabilityCheck, fn SearchFunc) AggregatedResults {
+	return a.run(ctx, check, func(ctx context.Context, _ int, indexer Indexer) ([]NZB, error) {
+		return fn(ctx, indexer.Client)
+	})
+}
+
+// SearchWithTVRage runs Client.SearchWithTVRage against all indexers that support it
+func (a *Aggregator) SearchWithTVRage(ctx context.Context, categories []int, tvRageID int, season int, episode int) AggregatedResults {
+	return a.Search(ctx, supports("tvsearch", "rid"), func(ctx context.Context, c Client) ([]NZB, error) {
+		return c.searchContext(ctx, c.tvValues("rid", tvRageID, categories, season, episode))
+	})
+}
+
+// SearchWithTVDB runs Client.SearchWithTVDB against all indexers that support it
+func (a *Aggregator) SearchWithTVDB(ctx context.Context, categories []int, tvDBID int, season int, episode int) AggregatedResults {
+	return a.Search(ctx, supports("tvsearch", "tvdbid"), func(ctx context.Context, c Client) ([]NZB, error) {
+		return c.searchContext(ctx, c.tvValues("tvdbid", tvDBID, categories, season, episode))
+	})
+}
+
+// SearchWithTVMaze runs Client.SearchWithTVMaze against all indexers that support it
+func (a *Aggregator) SearchWithTVMaze(ctx context.Context, categories []int, tvMazeID int, season int, episode int) AggregatedResults {
+	return a.Search(ctx, supports("tvsearch", "tvmazeid"), func(ctx context.Context, c Client) ([]NZB, error) {
+		return c.searchContext(ctx, c.tvValues("tvmazeid", tvMazeID, categories, season, episode))
+	})
+}
+
+// SearchWithIMDB runs Client.SearchWithIMDB against all indexers that support it
+func (a *Aggregator) SearchWithIMDB(ctx context.Context, categories []int, imdbID string) AggregatedResults {
+	return a.Search(ctx, supports("movie", "imdbid"), func(ctx context.Context, c Client) ([]NZB, error) {
+		return c.searchContext(ctx, c.imdbValues(categories, imdbID))
+	})
+}
+
+// SearchWithQuery runs Client.SearchWithQuery against all indexers that support it
+func (a *Aggregator) SearchWithQuery(ctx context.Context, categories []int, query string, searchType string) AggregatedResults {
+	return a.Search(ctx, supports(searchType, "q"), func(ctx context.Context, c Client) ([]NZB, error) {
+		return c.searchContext(ctx, c.queryValues(categories, query, searchType))
+	})
+}
+
+// SearchMedia runs the request against all indexers, sending each only the ids its capabilities list.
+// Indexers supporting none of the ids of the request are skipped unless the request has a query.
+func (a *Aggregator) SearchMedia(ctx context.Context, req SearchRequest) AggregatedResults {
+	check := func(caps Capabilities) bool {
+		return req.MediaIDs().IsZero() || req.Query != "" || !req.ForCapabilities(caps).IDs.IsZero()
+	}
+	return a.run(ctx, check, func(ctx context.Context, _ int, indexer Indexer) ([]NZB, error) {
+		indexerReq := req
+		if indexer.Capabilities != nil {
+			indexerReq = req.ForCapabilities(*indexer.Capabilities)
+		}
+		page, err := indexer.Client.Search(ctx, indexerReq)
+		return page.NZBs, err
+	})
+}
+
+// supports checks the capabilities for the given search type and parameter.
+// Search types the capabilities don't describe, like music or book, are never skipped.
+func supports(searchType string, param string) CapabilityCheck {
+	return func(caps Capabilities) bool {
+		switch searchType {
+		case "search", "tvsearch", "movie":
+			return caps.Supports(searchType, param)
+		}
+		return true
+	}
+}
+
+type indexerResult struct {
+	nzbs []NZB
+	err  error
+}
+
+func (a *Aggregator) run(ctx context.Context, check CapabilityCheck, fn func(ctx context.Context, i int, indexer Indexer) ([]NZB, error)) AggregatedResults {
+	indexers := a.Indexers()
+	reports := make([]IndexerReport, len(indexers))
+	results := make([][]NZB, len(indexers))
+
+	var wg sync.WaitGroup
+	for i, indexer := range indexers {
+		reports[i].Name = indexer.Name
+		if check != nil && indexer.Capabilities != nil && !check(*indexer.Capabilities) {
+			reports[i].Status = StatusSkipped
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, indexer Indexer) {
+			defer wg.Done()
+			start := time.Now()
+			nzbs, status, err := a.query(ctx, func(ctx context.Context) ([]NZB, error) {
+				return fn(ctx, i, indexer)
+			})
+			for k := range nzbs {
+				nzbs[k].SourceName = indexer.Name
+			}
+			results[i] = nzbs
+			reports[i].Status = status
+			reports[i].Err = err
+			reports[i].Results = len(nzbs)
+			reports[i].Duration = time.Since(start)
+		}(i, indexer)
+	}
+	wg.Wait()
+
+	var merged []NZB
+	for _, nzbs := range results {
+		merged = append(merged, nzbs...)
+	}
+	return AggregatedResults{
+		NZBs:    merged,
+		Reports: reports,
+	}
+}
+
+// query runs fn with a context that ends with ctx or after the timeout of the aggregator,
+// so the requests of an abandoned search are canceled
+func (a *Aggregator) query(ctx context.Context, fn func(ctx context.Context) ([]NZB, error)) ([]NZB, IndexerStatus, error) {
+	if a.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.timeout)
+		defer cancel()
+	}
+
+	// Buffered so a search that ignores ctx can still finish and be garbage collected
+	done := make(chan indexerResult, 1)
+	go func() {
+		nzbs, err := fn(ctx)
+		done <- indexerResult{nzbs: nzbs, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return nil, a.errorStatus(ctx, res.err), res.err
+		}
+		return res.nzbs, StatusOK, nil
+	case <-ctx.Done():
+		return nil, a.errorStatus(ctx, ctx.Err()), a.contextError(ctx)
+	}
+}
+
+// errorStatus tells a canceled request and a timeout apart from an error of the indexer
+func (a *Aggregator) errorStatus(ctx context.Context, err error) IndexerStatus {
+	switch {
+	case ctx.Err() == context.Canceled:
+		return StatusCanceled
+	case ctx.Err() == context.DeadlineExceeded || isTimeout(err):
+		return StatusTimeout
+	}
+	return StatusError
+}
+
+func (a *Aggregator) contextError(ctx context.Context) error {
+	if ctx.Err() == context.DeadlineExceeded && a.timeout > 0 {
+		return errors.Wrapf(ctx.Err(), "no response within %s", a.timeout)
+	}
+	return ctx.Err()
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := errors.Cause(err).(net.Error)
+	return ok && netErr.Timeout()
+}
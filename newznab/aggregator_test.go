@@ -0,0 +1,116 @@
+package newznab
+
+import (
+	"context"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregator(t *testing.T) {
+	feed, err := ioutil.ReadFile("../tests/fixtures/api/apikey_gibberish_cat_2040_imdbid_0364569_t_movie.xml")
+	require.NoError(t, err)
+
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(feed) // nolint:errcheck
+	}))
+	defer ok.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><error code="100" description="Invalid API Key"/>`)) // nolint:errcheck
+	}))
+	defer failing.Close()
+
+	abandoned := make(chan struct{}, 1)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			select {
+			case abandoned <- struct{}{}:
+			default:
+			}
+		case <-time.After(2 * time.Second):
+			w.Write(feed) // nolint:errcheck
+		}
+	}))
+	defer slow.Close()
+
+	noMovies := &Capabilities{}
+	noMovies.Searching.MovieSearch.Available = "no"
+
+	agg := NewAggregator(100*time.Millisecond,
+		Indexer{Name: "ok", Client: New(ok.URL, "gibberish", 1234, false)},
+		Indexer{Name: "failing", Client: New(failing.URL, "gibberish", 1234, false)},
+		Indexer{Name: "slow", Client: New(slow.URL, "gibberish", 1234, false)},
+		Indexer{Name: "skipped", Client: New(ok.URL, "gibberish", 1234, false), Capabilities: noMovies},
+	)
+
+	results := agg.SearchWithIMDB(context.Background(), []int{CategoryMovieHD}, "0364569")
+
+	t.Run("merged results", func(t *testing.T) {
+		require.NotEmpty(t, results.NZBs)
+		for _, nzb := range results.NZBs {
+			require.Equal(t, "ok", nzb.SourceName)
+		}
+	})
+
+	t.Run("indexer reports", func(t *testing.T) {
+		require.Len(t, results.Reports, 4)
+		require.Equal(t, StatusOK, results.Reports[0].Status)
+		require.Equal(t, len(results.NZBs), results.Reports[0].Results)
+		require.Equal(t, StatusError, results.Reports[1].Status)
+		require.EqualError(t, results.Reports[1].Err, "newznab api error 100: Invalid API Key")
+		require.Equal(t, StatusTimeout, results.Reports[2].Status)
+		require.Error(t, results.Reports[2].Err)
+		select {
+		case <-abandoned:
+		case <-time.After(time.Second):
+			t.Fatal("the request of the timed out indexer was not canceled")
+		}
+		require.Equal(t, StatusSkipped, results.Reports[3].Status)
+		require.Len(t, results.Failed(), 2)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		results := NewAggregator(0, Indexer{Name: "slow", Client: New(slow.URL, "gibberish", 1234, false)}).
+			SearchWithIMDB(ctx, []int{CategoryMovieHD}, "0364569")
+		require.Empty(t, results.NZBs)
+		require.Equal(t, StatusCanceled, results.Reports[0].Status)
+		require.Len(t, results.Failed(), 1)
+	})
+
+	t.Run("search types without capabilities", func(t *testing.T) {
+		results := agg.SearchWithQuery(context.Background(), nil, "music", "music")
+		require.Equal(t, StatusOK, results.Reports[3].Status, "music is not described by the capabilities")
+		results = agg.SearchWithQuery(context.Background(), nil, "movie", "movie")
+		require.Equal(t, StatusSkipped, results.Reports[3].Status)
+	})
+
+	t.Run("capabilities without supported params", func(t *testing.T) {
+		var caps Capabilities
+		require.NoError(t, xml.Unmarshal([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<caps><searching><search available="yes"/><tv-search available="yes"/><movie-search available="no"/></searching></caps>`), &caps))
+		require.True(t, caps.Supports("search", "q"))
+		require.True(t, caps.Supports("tvsearch", "q"))
+		require.False(t, caps.Supports("tvsearch", "tvdbid"))
+		require.False(t, caps.Supports("movie", "q"))
+
+		results := NewAggregator(time.Second, Indexer{Name: "ok", Client: New(ok.URL, "gibberish", 1234, false), Capabilities: &caps}).
+			SearchWithQuery(context.Background(), nil, "oldboy", "search")
+		require.Equal(t, StatusOK, results.Reports[0].Status)
+		require.NotEmpty(t, results.NZBs)
+	})
+
+	t.Run("indexers are copied", func(t *testing.T) {
+		indexers := agg.Indexers()
+		indexers[0].Name = "changed"
+		require.Equal(t, "ok", agg.Indexers()[0].Name)
+	})
+}
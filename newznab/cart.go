@@ -0,0 +1,65 @@
+package newznab
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// ErrCartNotSupported is returned by the cart methods when the indexer has no cart
+var ErrCartNotSupported = errors.New("indexer doesn't support carts")
+
+// cartFeed is the value of t on the rss endpoint that selects the cart instead of categories
+const cartFeed = "-2"
+
+// CartAdd adds the given NZB to the cart of the user with t=cartadd
+func (c Client) CartAdd(ctx context.Context, nzb NZB) error {
+	_, err := c.action(ctx, url.Values{
+		"t":  []string{"cartadd"},
+		"id": []string{nzb.ID},
+	})
+	return cartError(err)
+}
+
+// CartDelete removes the given NZB from the cart of the user with t=cartdel
+func (c Client) CartDelete(ctx context.Context, nzb NZB) error {
+	_, err := c.action(ctx, url.Values{
+		"t":  []string{"cartdel"},
+		"id": []string{nzb.ID},
+	})
+	return cartError(err)
+}
+
+// LoadCartFeed returns the NZBs in the cart of the user from the rss feed, authenticated like LoadRSSFeed.
+// When remove is set the indexer drops the returned NZBs from the cart.
+func (c Client) LoadCartFeed(ctx context.Context, remove bool) ([]NZB, error) {
+	vals := url.Values{
+		"t":  []string{cartFeed},
+		"dl": []string{"1"},
+	}
+	if remove {
+		vals.Set("del", "1")
+	}
+	c.rssAuth(vals)
+	page, err := c.processPageContext(ctx, vals, rssPath)
+	if apiErr, ok := err.(*APIError); ok && apiErr.Code == ErrorIncorrectParameter {
+		// Indexers without carts reject -2 as a category
+		return nil, ErrCartNotSupported
+	}
+	if err != nil {
+		return nil, cartError(err)
+	}
+	return page.NZBs, nil
+}
+
+// cartError turns the errors of indexers without carts into ErrCartNotSupported
+func cartError(err error) error {
+	if apiErr, ok := err.(*APIError); ok {
+		switch apiErr.Code {
+		case ErrorNoSuchFunction, ErrorFunctionNotAvailable:
+			return ErrCartNotSupported
+		}
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package newznab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCart(t *testing.T) {
+	var last *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = r
+		q := r.URL.Query()
+		switch {
+		case q.Get("id") == "unsupported":
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><error code="202" description="No such function"/>`)) // nolint:errcheck
+		case q.Get("t") == "cartadd" || q.Get("t") == "cartdel":
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><` + q.Get("t") + ` id="` + q.Get("id") + `"/>`)) // nolint:errcheck
+		case q.Get("r") == "rejects-cart":
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><error code="201" description="Incorrect parameter"/>`)) // nolint:errcheck
+		default:
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/"><channel><item><title>Show.S01E02</title><newznab:attr name="guid" value="abc"/></item></channel></rss>`)) // nolint:errcheck
+		}
+	}))
+	defer ts.Close()
+	client := New(ts.URL, "gibberish", 1234, false)
+	query := func() url.Values { return last.URL.Query() }
+
+	t.Run("add and delete", func(t *testing.T) {
+		require.NoError(t, client.CartAdd(context.Background(), NZB{ID: "abc"}))
+		require.Equal(t, "/api", last.URL.Path)
+		require.Equal(t, "cartadd", query().Get("t"))
+		require.Equal(t, "abc", query().Get("id"))
+		require.Equal(t, "gibberish", query().Get("apikey"))
+
+		require.NoError(t, client.CartDelete(context.Background(), NZB{ID: "abc"}))
+		require.Equal(t, "cartdel", query().Get("t"))
+	})
+
+	t.Run("feed", func(t *testing.T) {
+		nzbs, err := client.LoadCartFeed(context.Background(), false)
+		require.NoError(t, err)
+		require.Len(t, nzbs, 1)
+		require.Equal(t, "abc", nzbs[0].ID)
+		require.Equal(t, "/rss", last.URL.Path)
+		require.Equal(t, "-2", query().Get("t"))
+		require.Equal(t, "gibberish", query().Get("r"))
+		require.Equal(t, "1234", query().Get("i"))
+		require.Empty(t, query().Get("del"))
+
+		_, err = client.LoadCartFeed(context.Background(), true)
+		require.NoError(t, err)
+		require.Equal(t, "1", query().Get("del"))
+	})
+
+	t.Run("not supported", func(t *testing.T) {
+		require.Equal(t, ErrCartNotSupported, client.CartAdd(context.Background(), NZB{ID: "unsupported"}))
+		require.Equal(t, ErrCartNotSupported, client.CartDelete(context.Background(), NZB{ID: "unsupported"}))
+		_, err := New(ts.URL, "rejects-cart", 1234, false).LoadCartFeed(context.Background(), false)
+		require.Equal(t, ErrCartNotSupported, err)
+	})
+}
@@ -0,0 +1,330 @@
+package newznab
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Top level categories of the standard tree that have no constant above
+const (
+	// CategoryConsoleAll is for all console games
+	CategoryConsoleAll = 1000
+	// CategoryAudioAll is for all audio
+	CategoryAudioAll = 3000
+	// CategoryPCAll is for all PC software and games
+	CategoryPCAll = 4000
+	// CategoryXXXAll is for all adult releases
+	CategoryXXXAll = 6000
+	// CategoryBooksAll is for all books
+	CategoryBooksAll = 7000
+	// CategoryOtherAll is for everything else
+	CategoryOtherAll = 8000
+)
+
+// Category is a newznab category, Parent is 0 for top level categories
+type Category struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name,omitempty"`
+	Parent int    `json:"parent,omitempty"`
+}
+
+// IsParent reports whether the category is a top level category
+func (c Category) IsParent() bool {
+	return c.Parent == 0
+}
+
+// CategoryTree is a read-only set of categories with their parents and children
+type CategoryTree struct {
+	categories map[int]Category
+	children   map[int][]int
+}
+
+var defaultCategories = NewCategoryTree(
+	Category{ID: 1000, Name: "Console"},
+	Category{ID: 1010, Name: "NDS", Parent: 1000},
+	Category{ID: 1020, Name: "PSP", Parent: 1000},
+	Category{ID: 1030, Name: "Wii", Parent: 1000},
+	Category{ID: 1040, Name: "XBox", Parent: 1000},
+	Category{ID: 1050, Name: "XBox 360", Parent: 1000},
+	Category{ID: 1060, Name: "Wiiware", Parent: 1000},
+	Category{ID: 1070, Name: "XBox 360 DLC", Parent: 1000},
+	Category{ID: 1080, Name: "PS3", Parent: 1000},
+	Category{ID: 1090, Name: "Other", Parent: 1000},
+	Category{ID: 1110, Name: "3DS", Parent: 1000},
+	Category{ID: 1120, Name: "PS Vita", Parent: 1000},
+	Category{ID: 1130, Name: "WiiU", Parent: 1000},
+	Category{ID: 1140, Name: "XBox One", Parent: 1000},
+	Category{ID: 1180, Name: "PS4", Parent: 1000},
+
+	Category{ID: 2000, Name: "Movies"},
+	Category{ID: 2010, Name: "Foreign", Parent: 2000},
+	Category{ID: 2020, Name: "Other", Parent: 2000},
+	Category{ID: 2030, Name: "SD", Parent: 2000},
+	Category{ID: 2040, Name: "HD", Parent: 2000},
+	Category{ID: 2045, Name: "UHD", Parent: 2000},
+	Category{ID: 2050, Name: "BluRay", Parent: 2000},
+	Category{ID: 2060, Name: "3D", Parent: 2000},
+
+	Category{ID: 3000, Name: "Audio"},
+	Category{ID: 3010, Name: "MP3", Parent: 3000},
+	Category{ID: 3020, Name: "Video", Parent: 3000},
+	Category{ID: 3030, Name: "Audiobook", Parent: 3000},
+	Category{ID: 3040, Name: "Lossless", Parent: 3000},
+	Category{ID: 3050, Name: "Other", Parent: 3000},
+	Category{ID: 3060, Name: "Foreign", Parent: 3000},
+
+	Category{ID: 4000, Name: "PC"},
+	Category{ID: 4010, Name: "0day", Parent: 4000},
+	Category{ID: 4020, Name: "ISO", Parent: 4000},
+	Category{ID: 4030, Name: "Mac", Parent: 4000},
+	Category{ID: 4040, Name: "Mobile-Other", Parent: 4000},
+	Category{ID: 4050, Name: "Games", Parent: 4000},
+	Category{ID: 4060, Name: "Mobile-iOS", Parent: 4000},
+	Category{ID: 4070, Name: "Mobile-Android", Parent: 4000},
+
+	Category{ID: 5000, Name: "TV"},
+	Category{ID: 5010, Name: "WEB-DL", Parent: 5000},
+	Category{ID: 5020, Name: "Foreign", Parent: 5000},
+	Category{ID: 5030, Name: "SD", Parent: 5000},
+	Category{ID: 5040, Name: "HD", Parent: 5000},
+	Category{ID: 5045, Name: "UHD", Parent: 5000},
+	Category{ID: 5050, Name: "Other", Parent: 5000},
+	Category{ID: 5060, Name: "Sport", Parent: 5000},
+	Category{ID: 5070, Name: "Anime", Parent: 5000},
+	Category{ID: 5080, Name: "Documentary", Parent: 5000},
+
+	Category{ID: 6000, Name: "XXX"},
+	Category{ID: 6010, Name: "DVD", Parent: 6000},
+	Category{ID: 6020, Name: "WMV", Parent: 6000},
+	Category{ID: 6030, Name: "XviD", Parent: 6000},
+	Category{ID: 6040, Name: "x264", Parent: 6000},
+	Category{ID: 6045, Name: "UHD", Parent: 6000},
+	Category{ID: 6050, Name: "Pack", Parent: 6000},
+	Category{ID: 6060, Name: "ImageSet", Parent: 6000},
+	Category{ID: 6070, Name: "Other", Parent: 6000},
+
+	Category{ID: 7000, Name: "Books"},
+	Category{ID: 7010, Name: "Mags", Parent: 7000},
+	Category{ID: 7020, Name: "Ebook", Parent: 7000},
+	Category{ID: 7030, Name: "Comics", Parent: 7000},
+	Category{ID: 7040, Name: "Technical", Parent: 7000},
+	Category{ID: 7050, Name: "Other", Parent: 7000},
+	Category{ID: 7060, Name: "Foreign", Parent: 7000},
+
+	Category{ID: 8000, Name: "Other"},
+	Category{ID: 8010, Name: "Misc", Parent: 8000},
+	Category{ID: 8020, Name: "Hashed", Parent: 8000},
+)
+
+// DefaultCategories returns the standard newznab category tree
+func DefaultCategories() *CategoryTree {
+	return defaultCategories
+}
+
+// NewCategoryTree returns a tree of the given categories, a later category replaces an earlier one with the same id
+func NewCategoryTree(categories ...Category) *CategoryTree {
+	t := &CategoryTree{
+		categories: make(map[int]Category, len(categories)),
+		children:   map[int][]int{},
+	}
+	for _, category := range categories {
+		t.categories[category.ID] = category
+	}
+	for _, category := range t.categories {
+		if category.Parent != 0 {
+			t.children[category.Parent] = append(t.children[category.Parent], category.ID)
+		}
+	}
+	for _, children := range t.children {
+		sort.Ints(children)
+	}
+	return t
+}
+
+// Merge returns a tree with the categories listed in the capabilities of an indexer added to this one.
+// The names used by the indexer replace the standard ones.
+func (t *CategoryTree) Merge(caps Capabilities) *CategoryTree {
+	categories := t.Categories()
+	for _, capsCategory := range caps.Categories.Category {
+		id, err := strconv.Atoi(capsCategory.ID)
+		if err != nil {
+			continue
+		}
+		categories = append(categories, Category{ID: id, Name: capsCategory.Name})
+		for _, subcat := range capsCategory.Subcat {
+			subID, err := strconv.Atoi(subcat.ID)
+			if err != nil {
+				continue
+			}
+			categories = append(categories, Category{ID: subID, Name: subcat.Name, Parent: id})
+		}
+	}
+	return NewCategoryTree(categories...)
+}
+
+// Categories returns all categories ordered by id
+func (t *CategoryTree) Categories() []Category {
+	categories := make([]Category, 0, len(t.categories))
+	for _, category := range t.categories {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+	return categories
+}
+
+// Get returns the category with the given id. Unknown ids in a thousand of the standard tree,
+// like 5999, are returned without a name under their thousand.
+func (t *CategoryTree) Get(id int) (Category, bool) {
+	if category, ok := t.categories[id]; ok {
+		return category, true
+	}
+	if parent := id / 1000 * 1000; id < 10000 && parent != id {
+		if _, ok := t.categories[parent]; ok {
+			return Category{ID: id, Parent: parent}, true
+		}
+	}
+	return Category{ID: id}, false
+}
+
+// ParentOf returns the parent of the given category, a top level category is its own parent
+func (t *CategoryTree) ParentOf(id int) (Category, bool) {
+	category, ok := t.Get(id)
+	if !ok {
+		return Category{}, false
+	}
+	if category.IsParent() {
+		return category, true
+	}
+	return t.Get(category.Parent)
+}
+
+// ChildrenOf returns the subcategories of the given category ordered by id
+func (t *CategoryTree) ChildrenOf(id int) []Category {
+	var children []Category
+	for _, child := range t.children[id] {
+		children = append(children, t.categories[child])
+	}
+	return children
+}
+
+// Expand returns the given categories followed by the subcategories of the top level ones, without duplicates.
+// Use it for indexers that don't include subcategories when searching a parent.
+func (t *CategoryTree) Expand(ids ...int) []int {
+	seen := map[int]bool{}
+	var expanded []int
+	add := func(id int) {
+		if !seen[id] {
+			seen[id] = true
+			expanded = append(expanded, id)
+		}
+	}
+	for _, id := range ids {
+		add(id)
+		for _, child := range t.children[id] {
+			add(child)
+		}
+	}
+	return expanded
+}
+
+// NameOf returns the name of a category with the name of its parent, like "TV > HD".
+// Categories without a name are named by their id.
+func (t *CategoryTree) NameOf(id int) string {
+	category, _ := t.Get(id)
+	name := category.Name
+	if name == "" {
+		name = strconv.Itoa(id)
+	}
+	if category.IsParent() {
+		return name
+	}
+	parent, _ := t.Get(category.Parent)
+	if parent.Name == "" {
+		return name
+	}
+	return parent.Name + " > " + name
+}
+
+// CapsCategories returns the tree in the form of the capabilities, for servers
+func (t *CategoryTree) CapsCategories() []CapsCategory {
+	var caps []CapsCategory
+	for _, category := range t.Categories() {
+		if !category.IsParent() {
+			continue
+		}
+		capsCategory := CapsCategory{ID: strconv.Itoa(category.ID), Name: category.Name}
+		for _, child := range t.ChildrenOf(category.ID) {
+			capsCategory.Subcat = append(capsCategory.Subcat, CapsSubcat{ID: strconv.Itoa(child.ID), Name: child.Name})
+		}
+		caps = append(caps, capsCategory)
+	}
+	return caps
+}
+
+// ParentOf returns the parent of the given category in the standard tree
+func ParentOf(id int) (Category, bool) {
+	return defaultCategories.ParentOf(id)
+}
+
+// ChildrenOf returns the subcategories of the given category in the standard tree
+func ChildrenOf(id int) []Category {
+	return defaultCategories.ChildrenOf(id)
+}
+
+// Expand returns the given categories and the subcategories of the top level ones in the standard tree
+func Expand(ids ...int) []int {
+	return defaultCategories.Expand(ids...)
+}
+
+// NameOf returns the name of the given category in the standard tree, like "TV > HD"
+func NameOf(id int) string {
+	return defaultCategories.NameOf(id)
+}
+
+// WithCategories returns a copy of this client that names the categories of results with the given tree
+// instead of the standard one
+func (c Client) WithCategories(tree *CategoryTree) Client {
+	c.categories = tree
+	return c
+}
+
+// WithDefaultCategories returns a copy of this client that searches and loads RSS feeds of the given categories
+// when a request doesn't ask for any
+func (c Client) WithDefaultCategories(categories []int) Client {
+	c.defaultCategories = append([]int(nil), categories...)
+	return c
+}
+
+// LoadCategories returns the standard tree merged with the categories of the indexer capabilities
+func (c Client) LoadCategories(ctx context.Context) (*CategoryTree, error) {
+	caps, err := c.caps(ctx, url.Values{"t": []string{"caps"}})
+	if err != nil {
+		return nil, err
+	}
+	return DefaultCategories().Merge(caps), nil
+}
+
+func (c Client) categoryTree() *CategoryTree {
+	if c.categories == nil {
+		return defaultCategories
+	}
+	return c.categories
+}
+
+// parseCategories sets the typed categories of the NZB from its category attributes
+func (p *attrParser) parseCategories(nzb *NZB, tree *CategoryTree) {
+	for _, value := range nzb.Category {
+		id, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			p.warn(Attribute{Name: "category", Value: value}, errors.Wrap(err, "invalid category id"))
+			continue
+		}
+		category, _ := tree.Get(id)
+		nzb.Categories = append(nzb.Categories, category)
+	}
+}
@@ -0,0 +1,107 @@
+package newznab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoryTree(t *testing.T) {
+	t.Run("standard tree", func(t *testing.T) {
+		for _, id := range []int{CategoryConsoleAll, CategoryMovieAll, CategoryAudioAll, CategoryPCAll, CategoryTVAll, CategoryXXXAll, CategoryBooksAll, CategoryOtherAll} {
+			category, ok := DefaultCategories().Get(id)
+			require.True(t, ok, id)
+			require.True(t, category.IsParent())
+			require.NotEmpty(t, ChildrenOf(id), id)
+		}
+		require.Equal(t, "TV > HD", NameOf(CategoryTVHD))
+		require.Equal(t, "Movies", NameOf(CategoryMovieAll))
+		require.Equal(t, "Audio > Lossless", NameOf(3040))
+		require.Equal(t, "Books > Ebook", NameOf(7020))
+		require.Equal(t, "XXX > UHD", NameOf(6045))
+		require.Equal(t, "123456", NameOf(123456))
+	})
+
+	t.Run("parents", func(t *testing.T) {
+		parent, ok := ParentOf(CategoryTVSD)
+		require.True(t, ok)
+		require.Equal(t, Category{ID: 5000, Name: "TV"}, parent)
+
+		parent, ok = ParentOf(CategoryTVAll)
+		require.True(t, ok)
+		require.Equal(t, 5000, parent.ID)
+
+		parent, ok = ParentOf(5999)
+		require.True(t, ok, "unknown subcategories belong to their thousand")
+		require.Equal(t, 5000, parent.ID)
+
+		for _, id := range []int{8010, 8020} {
+			parent, ok = ParentOf(id)
+			require.True(t, ok, id)
+			require.Equal(t, CategoryOtherAll, parent.ID, id)
+		}
+
+		_, ok = ParentOf(123456)
+		require.False(t, ok)
+	})
+
+	t.Run("expand", func(t *testing.T) {
+		expanded := Expand(CategoryTVAll)
+		require.Equal(t, []int{5000, 5010, 5020, 5030, 5040, 5045, 5050, 5060, 5070, 5080}, expanded)
+		require.Equal(t, []int{CategoryTVHD, CategoryMovieAll, 2010, 2020, 2030, 2040, 2045, 2050, 2060},
+			Expand(CategoryTVHD, CategoryMovieAll, CategoryMovieHD))
+	})
+
+	t.Run("merge with capabilities", func(t *testing.T) {
+		var caps Capabilities
+		caps.Categories.Category = []CapsCategory{
+			{ID: "5000", Name: "TV", Subcat: []CapsSubcat{{ID: "5040", Name: "HD 720p/1080p"}, {ID: "5090", Name: "Kids"}}},
+			{ID: "100000", Name: "Custom", Subcat: []CapsSubcat{{ID: "100001", Name: "Stuff"}}},
+			{ID: "bad", Name: "Ignored"},
+		}
+		tree := DefaultCategories().Merge(caps)
+		require.Equal(t, "TV > HD 720p/1080p", tree.NameOf(CategoryTVHD))
+		require.Equal(t, "TV > Kids", tree.NameOf(5090))
+		require.Equal(t, "Custom > Stuff", tree.NameOf(100001))
+		require.Equal(t, []Category{{ID: 100001, Name: "Stuff", Parent: 100000}}, tree.ChildrenOf(100000))
+		require.Contains(t, tree.Expand(CategoryTVAll), 5090)
+		require.Equal(t, "TV > HD", NameOf(CategoryTVHD), "the standard tree is unchanged")
+
+		require.Len(t, DefaultCategories().CapsCategories(), 8)
+		var standard Capabilities
+		standard.Categories.Category = DefaultCategories().CapsCategories()
+		require.Equal(t, DefaultCategories().Categories(), NewCategoryTree().Merge(standard).Categories())
+	})
+}
+
+func TestResultCategories(t *testing.T) {
+	const caps = `<?xml version="1.0" encoding="UTF-8"?>
+<caps><categories><category id="5000" name="TV"><subcat id="5040" name="HD"/><subcat id="5090" name="Kids"/></category></categories></caps>`
+	const feed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/"><channel><item><title>kids show</title>
+<newznab:attr name="category" value="5000"/><newznab:attr name="category" value="5090"/><newznab:attr name="category" value="TV"/></item></channel></rss>`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("t") == "caps" {
+			w.Write([]byte(caps)) // nolint:errcheck
+			return
+		}
+		w.Write([]byte(feed)) // nolint:errcheck
+	}))
+	defer ts.Close()
+	client := New(ts.URL, "gibberish", 1234, false)
+
+	page, err := client.Search(context.Background(), SearchRequest{Query: "kids"})
+	require.NoError(t, err)
+	require.Equal(t, []Category{{ID: 5000, Name: "TV"}, {ID: 5090, Parent: 5000}}, page.NZBs[0].Categories)
+	require.Len(t, page.Warnings, 1)
+	require.Equal(t, "attr:category", page.Warnings[0].Field)
+
+	tree, err := client.LoadCategories(context.Background())
+	require.NoError(t, err)
+	page, err = client.WithCategories(tree).Search(context.Background(), SearchRequest{Query: "kids"})
+	require.NoError(t, err)
+	require.Equal(t, Category{ID: 5090, Name: "Kids", Parent: 5000}, page.NZBs[0].Categories[1])
+}
@@ -0,0 +1,182 @@
+package newznab
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Checkpoint records how far a feed has been consumed
+type Checkpoint struct {
+	LastID    string    `json:"last_id,omitempty"`
+	PubDate   time.Time `json:"pub_date"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsZero reports whether the checkpoint records no progress
+func (c Checkpoint) IsZero() bool {
+	return c.LastID == "" && c.PubDate.IsZero()
+}
+
+// CheckpointStore persists feed checkpoints so polling can resume after a restart
+type CheckpointStore interface {
+	// Load returns the checkpoint stored under key, or a zero Checkpoint if there is none
+	Load(key string) (Checkpoint, error)
+	// Save stores the checkpoint under key
+	Save(key string, checkpoint Checkpoint) error
+}
+
+// CheckpointKey returns the key for the feed of the given indexer and category set.
+// The order of the categories does not matter.
+func CheckpointKey(indexer string, categories []int) string {
+	sorted := append([]int(nil), categories...)
+	sort.Ints(sorted)
+	cats := make([]string, 0, len(sorted))
+	for _, cat := range sorted {
+		cats = append(cats, strconv.Itoa(cat))
+	}
+	return indexer + "|" + strings.Join(cats, ",")
+}
+
+// FileCheckpointStore is a CheckpointStore backed by a single JSON file
+type FileCheckpointStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileCheckpointStore returns a CheckpointStore that keeps its checkpoints in the JSON file at path.
+// The file is created on the first save.
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+// Load returns the checkpoint stored under key
+func (s *FileCheckpointStore) Load(key string) (Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	checkpoints, err := s.read()
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	return checkpoints[key], nil
+}
+
+// Save stores the checkpoint under key.
+// The file is replaced atomically so a crash never leaves a partially written file behind.
+func (s *FileCheckpointStore) Save(key string, checkpoint Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	checkpoints, err := s.read()
+	if err != nil {
+		return err
+	}
+	checkpoints[key] = checkpoint
+
+	data, err := json.MarshalIndent(checkpoints, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal checkpoints")
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create checkpoint file")
+	}
+	defer os.Remove(tmp.Name()) // nolint:errcheck
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to write checkpoint file")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), s.path), "failed to replace checkpoint file")
+}
+
+func (s *FileCheckpointStore) read() (map[string]Checkpoint, error) {
+	checkpoints := map[string]Checkpoint{}
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return checkpoints, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read checkpoint file")
+	}
+	if err = json.Unmarshal(data, &checkpoints); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal checkpoint file")
+	}
+	return checkpoints, nil
+}
+
+// MemoryCheckpointStore is a CheckpointStore that only lives as long as the process, mostly useful for tests
+type MemoryCheckpointStore struct {
+	mu          sync.Mutex
+	checkpoints map[string]Checkpoint
+}
+
+// NewMemoryCheckpointStore returns an empty MemoryCheckpointStore
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{checkpoints: map[string]Checkpoint{}}
+}
+
+// Load returns the checkpoint stored under key
+func (s *MemoryCheckpointStore) Load(key string) (Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkpoints[key], nil
+}
+
+// Save stores the checkpoint under key
+func (s *MemoryCheckpointStore) Save(key string, checkpoint Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoints[key] = checkpoint
+	return nil
+}
+
+// LoadRSSFeedSinceCheckpoint fetches the NZBs published since the checkpoint stored under key, oldest first.
+// Without a stored checkpoint only the first page is fetched.
+// The checkpoint is only moved forward when the returned commit function is called,
+// so call it once the NZBs have been handled.
+func (c Client) LoadRSSFeedSinceCheckpoint(store CheckpointStore, key string, categories []int, num int, maxRequests int) ([]NZB, func() error, error) {
+	checkpoint, err := store.Load(key)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to load checkpoint")
+	}
+	if checkpoint.IsZero() {
+		// There is no id to page back to, the whole feed would be fetched
+		maxRequests = 1
+	}
+	nzbs, err := c.LoadRSSFeedUntilNZBID(categories, num, checkpoint.LastID, maxRequests)
+	if err != nil {
+		return nil, nil, err
+	}
+	fresh := make([]NZB, 0, len(nzbs))
+	for i := len(nzbs) - 1; i >= 0; i-- {
+		if !checkpoint.PubDate.IsZero() && !nzbs[i].PubDate.IsZero() && nzbs[i].PubDate.Before(checkpoint.PubDate) {
+			continue
+		}
+		fresh = append(fresh, nzbs[i])
+	}
+
+	commit := func() error { return nil }
+	if len(fresh) > 0 {
+		newest := fresh[len(fresh)-1]
+		commit = func() error {
+			return store.Save(key, Checkpoint{
+				LastID:    watchKey(newest),
+				PubDate:   newest.PubDate,
+				UpdatedAt: time.Now(),
+			})
+		}
+	}
+	return fresh, commit, nil
+}
@@ -0,0 +1,162 @@
+package newznab
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoints")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint:errcheck
+
+	t.Run("key ignores category order", func(t *testing.T) {
+		require.Equal(t, "indexer|2000,5000", CheckpointKey("indexer", []int{CategoryTVAll, CategoryMovieAll}))
+		require.Equal(t, CheckpointKey("indexer", []int{5000, 2000}), CheckpointKey("indexer", []int{2000, 5000}))
+	})
+
+	t.Run("file store", func(t *testing.T) {
+		path := filepath.Join(dir, "checkpoints.json")
+		store := NewFileCheckpointStore(path)
+
+		checkpoint, err := store.Load("missing")
+		require.NoError(t, err)
+		require.True(t, checkpoint.IsZero())
+
+		saved := Checkpoint{LastID: "guid-1", PubDate: time.Date(2017, 5, 4, 12, 0, 0, 0, time.UTC)}
+		require.NoError(t, store.Save("a", saved))
+		require.NoError(t, store.Save("b", Checkpoint{LastID: "guid-2"}))
+
+		// A fresh store reads what the previous one wrote
+		checkpoint, err = NewFileCheckpointStore(path).Load("a")
+		require.NoError(t, err)
+		require.Equal(t, saved.LastID, checkpoint.LastID)
+		require.True(t, saved.PubDate.Equal(checkpoint.PubDate))
+
+		files, err := ioutil.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, files, 1, "temporary files should be cleaned up")
+	})
+
+	feed := &fakeFeed{start: time.Date(2017, 5, 4, 12, 0, 0, 0, time.UTC)}
+	feed.add(20)
+	ts := httptest.NewServer(feed)
+	defer ts.Close()
+	client := New(ts.URL, "gibberish", 1234, false)
+
+	t.Run("rss without checkpoint", func(t *testing.T) {
+		var requests int
+		counted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			feed.ServeHTTP(w, r)
+		}))
+		defer counted.Close()
+
+		nzbs, commit, err := New(counted.URL, "gibberish", 1234, false).LoadRSSFeedSinceCheckpoint(NewMemoryCheckpointStore(), "feed", nil, 5, 0)
+		require.NoError(t, err)
+		require.Equal(t, 1, requests, "only the first page is fetched")
+		require.Len(t, nzbs, 5)
+		require.Equal(t, "guid-19", nzbs[4].ID)
+		require.NoError(t, commit())
+	})
+
+	t.Run("rss since checkpoint", func(t *testing.T) {
+		store := NewMemoryCheckpointStore()
+		require.NoError(t, store.Save("feed", Checkpoint{LastID: "guid-14"}))
+
+		nzbs, commit, err := client.LoadRSSFeedSinceCheckpoint(store, "feed", nil, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, nzbs, 5)
+		require.Equal(t, "guid-15", nzbs[0].ID)
+
+		t.Run("not committed before ack", func(t *testing.T) {
+			again, _, err := client.LoadRSSFeedSinceCheckpoint(store, "feed", nil, 10, 0)
+			require.NoError(t, err)
+			require.Len(t, again, 5)
+		})
+
+		t.Run("committed after ack", func(t *testing.T) {
+			require.NoError(t, commit())
+			again, _, err := client.LoadRSSFeedSinceCheckpoint(store, "feed", nil, 10, 0)
+			require.NoError(t, err)
+			require.Empty(t, again)
+		})
+	})
+
+	t.Run("unparseable dates after a checkpoint", func(t *testing.T) {
+		undated := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/"><channel>
+<item><title>undated</title><pubDate>sometime yesterday</pubDate><newznab:attr name="guid" value="guid-2"/></item>
+<item><title>known</title><pubDate>Thu, 04 May 2017 12:00:00 +0000</pubDate><newznab:attr name="guid" value="guid-1"/></item>
+</channel></rss>`)) // nolint:errcheck
+		}))
+		defer undated.Close()
+		store := NewMemoryCheckpointStore()
+		require.NoError(t, store.Save("feed", Checkpoint{LastID: "guid-1", PubDate: time.Date(2017, 5, 4, 12, 0, 0, 0, time.UTC)}))
+
+		nzbs, _, err := New(undated.URL, "gibberish", 1234, false).LoadRSSFeedSinceCheckpoint(store, "feed", nil, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, nzbs, 1, "items without a date are not older than the checkpoint")
+		require.Equal(t, "guid-2", nzbs[0].ID)
+		require.True(t, nzbs[0].PubDate.IsZero())
+	})
+
+	t.Run("feed without ids", func(t *testing.T) {
+		var requests int
+		anonymous := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel>
+<item><title>second</title><pubDate>Thu, 04 May 2017 12:01:00 +0000</pubDate><enclosure url="https://example.com/getnzb/2.nzb" type="application/x-nzb"/></item>
+<item><title>first</title><pubDate>Thu, 04 May 2017 12:00:00 +0000</pubDate><enclosure url="https://example.com/getnzb/1.nzb" type="application/x-nzb"/></item>
+</channel></rss>`)) // nolint:errcheck
+		}))
+		defer anonymous.Close()
+		client := New(anonymous.URL, "gibberish", 1234, false)
+		store := NewMemoryCheckpointStore()
+
+		w := NewWatcher(client, WatcherOptions{Num: 10, Checkpoints: store, CheckpointKey: "feed"})
+		_, err := w.Poll(context.Background())
+		require.NoError(t, err)
+		checkpoint, err := store.Load("feed")
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com/getnzb/2.nzb", checkpoint.LastID)
+
+		requests = 0
+		nzbs, _, err := client.LoadRSSFeedSinceCheckpoint(store, "feed", nil, 10, 5)
+		require.NoError(t, err)
+		require.Empty(t, nzbs)
+		require.Equal(t, 1, requests, "the watcher checkpoint is found on the first page")
+	})
+
+	t.Run("watcher resumes from checkpoint", func(t *testing.T) {
+		store := NewMemoryCheckpointStore()
+		opts := WatcherOptions{Num: 10, Checkpoints: store, CheckpointKey: "feed"}
+
+		w := NewWatcher(client, opts)
+		nzbs, err := w.Poll(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, nzbs, "first poll primes the checkpoint")
+
+		feed.add(3)
+		nzbs, err = w.Poll(context.Background())
+		require.NoError(t, err)
+		require.Len(t, nzbs, 3)
+		require.NoError(t, w.Ack(nzbs[0]))
+
+		// Simulate a crash before the last two releases were handled
+		restarted := NewWatcher(client, opts)
+		nzbs, err = restarted.Poll(context.Background())
+		require.NoError(t, err)
+		require.Len(t, nzbs, 2)
+		require.Equal(t, "guid-21", nzbs[0].ID)
+		require.Equal(t, "guid-22", nzbs[1].ID)
+	})
+}
@@ -0,0 +1,121 @@
+package newznab
+
+import (
+	"context"
+	"encoding/xml"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// PopulateComments fills in the Comments for the given NZB
+func (c Client) PopulateComments(nzb *NZB) error {
+	comments, err := c.Comments(context.Background(), *nzb, 0, 0)
+	if err != nil {
+		return err
+	}
+	nzb.Comments = append(nzb.Comments, comments...)
+	return nil
+}
+
+// Comments returns a page of the comments left on the given NZB.
+// A limit of 0 leaves the page size up to the indexer.
+func (c Client) Comments(ctx context.Context, nzb NZB, offset int, limit int) ([]Comment, error) {
+	vals := url.Values{
+		"t":      []string{"comments"},
+		"id":     []string{nzb.ID},
+		"apikey": []string{c.apikey},
+	}
+	if offset > 0 {
+		vals.Set("offset", strconv.Itoa(offset))
+	}
+	if limit > 0 {
+		vals.Set("limit", strconv.Itoa(limit))
+	}
+	commentsURL, err := c.buildURL(vals, apiPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.getURLContext(ctx, commentsURL)
+	if err != nil {
+		return nil, err
+	}
+	var resp commentResponse
+	err = xml.Unmarshal(data, &resp)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal comments xml data")
+	}
+	if resp.ErrorCode != 0 {
+		return nil, &APIError{Code: resp.ErrorCode, Description: resp.ErrorDesc}
+	}
+
+	comments := make([]Comment, 0, len(resp.Channel.Comments))
+	for _, rawComment := range resp.Channel.Comments {
+		comments = append(comments, rawComment.toComment())
+	}
+	return comments, nil
+}
+
+// AddComment posts a comment on the given NZB with t=commentadd and returns it with the ID assigned by the indexer
+func (c Client) AddComment(ctx context.Context, nzb NZB, text string) (Comment, error) {
+	id, err := c.action(ctx, url.Values{
+		"t":    []string{"commentadd"},
+		"id":   []string{nzb.ID},
+		"text": []string{text},
+	})
+	if err != nil {
+		return Comment{}, err
+	}
+	return Comment{ID: id, Content: text}, nil
+}
+
+type commentResponse struct {
+	ErrorCode int    `xml:"code,attr"`
+	ErrorDesc string `xml:"description,attr"`
+	Channel   struct {
+		Comments []rssComment `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssComment struct {
+	Title       string      `xml:"title"`
+	GUID        string      `xml:"guid"`
+	Author      string      `xml:"author"`
+	Description string      `xml:"description"`
+	PubDate     string      `xml:"pubDate"`
+	Attributes  []Attribute `xml:"attr"`
+}
+
+// toComment maps a comment item, the title holds the name of the poster unless an author is given
+func (raw rssComment) toComment() Comment {
+	comment := Comment{
+		GUID:    strings.TrimSpace(raw.GUID),
+		Author:  raw.Author,
+		Title:   raw.Title,
+		Content: raw.Description,
+	}
+	for _, attr := range raw.Attributes {
+		switch attr.Name {
+		case "id":
+			comment.ID = attr.Value
+		case "author", "username":
+			comment.Author = attr.Value
+		case "rating":
+			if rating, err := strconv.Atoi(attr.Value); err == nil {
+				comment.Rating = rating
+			}
+		}
+	}
+	if comment.Author == "" {
+		comment.Author = raw.Title
+	}
+	if parsedPubDate, err := parseDate(raw.PubDate); err != nil {
+		log.WithError(err).WithField("pubdate", raw.PubDate).Debug("failed to parse comment date")
+	} else {
+		comment.PubDate = parsedPubDate
+	}
+	return comment
+}
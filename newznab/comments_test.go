@@ -0,0 +1,100 @@
+package newznab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const commentsXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/">
+<channel>
+  <item>
+    <title>username_of_poster</title>
+    <guid isPermaLink="true">http://servername.com/rss/viewnzb/e9c515e02346086e3a477a5436d7bc8c</guid>
+    <pubDate>Sun, 06 Jun 2010 17:29:23 +0100</pubDate>
+    <description>Comment about the item</description>
+  </item>
+  <item>
+    <title>Re: great release</title>
+    <pubDate>2017-05-04T12:00:00Z</pubDate>
+    <description>Thanks!</description>
+    <newznab:attr name="id" value="42"/>
+    <newznab:attr name="author" value="someone"/>
+    <newznab:attr name="rating" value="9"/>
+  </item>
+</channel>
+</rss>`
+
+func TestComments(t *testing.T) {
+	var last url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = r.URL.Query()
+		switch {
+		case last.Get("id") == "missing":
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><error code="300" description="No such item"/>`)) // nolint:errcheck
+		case last.Get("t") == "commentadd":
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><commentadd id="43"/>`)) // nolint:errcheck
+		default:
+			w.Write([]byte(commentsXML)) // nolint:errcheck
+		}
+	}))
+	defer ts.Close()
+	client := New(ts.URL, "gibberish", 1234, false)
+
+	t.Run("fields", func(t *testing.T) {
+		comments, err := client.Comments(context.Background(), NZB{ID: "abc"}, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, comments, 2)
+
+		require.Equal(t, "username_of_poster", comments[0].Author, "the title holds the poster")
+		require.Equal(t, "http://servername.com/rss/viewnzb/e9c515e02346086e3a477a5436d7bc8c", comments[0].GUID)
+		require.Equal(t, "Comment about the item", comments[0].Content)
+		require.True(t, time.Date(2010, 6, 6, 16, 29, 23, 0, time.UTC).Equal(comments[0].PubDate))
+
+		require.Equal(t, "42", comments[1].ID)
+		require.Equal(t, "someone", comments[1].Author)
+		require.Equal(t, "Re: great release", comments[1].Title)
+		require.Equal(t, 9, comments[1].Rating)
+		require.True(t, time.Date(2017, 5, 4, 12, 0, 0, 0, time.UTC).Equal(comments[1].PubDate))
+
+		require.Empty(t, last.Get("offset"))
+		require.Empty(t, last.Get("limit"))
+	})
+
+	t.Run("paging", func(t *testing.T) {
+		_, err := client.Comments(context.Background(), NZB{ID: "abc"}, 20, 10)
+		require.NoError(t, err)
+		require.Equal(t, "comments", last.Get("t"))
+		require.Equal(t, "20", last.Get("offset"))
+		require.Equal(t, "10", last.Get("limit"))
+	})
+
+	t.Run("populate", func(t *testing.T) {
+		nzb := NZB{ID: "abc"}
+		require.NoError(t, client.PopulateComments(&nzb))
+		require.Len(t, nzb.Comments, 2)
+	})
+
+	t.Run("add", func(t *testing.T) {
+		comment, err := client.AddComment(context.Background(), NZB{ID: "abc"}, "nice one")
+		require.NoError(t, err)
+		require.Equal(t, "43", comment.ID)
+		require.Equal(t, "nice one", comment.Content)
+		require.Equal(t, "abc", last.Get("id"))
+		require.Equal(t, "nice one", last.Get("text"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := client.Comments(context.Background(), NZB{ID: "missing"}, 0, 0)
+		require.EqualError(t, err, "newznab api error 300: No such item")
+
+		_, err = client.AddComment(context.Background(), NZB{ID: "missing"}, "nice one")
+		require.EqualError(t, err, "newznab api error 300: No such item")
+	})
+}
@@ -0,0 +1,104 @@
+package newznab
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// dateFormats are tried in order by parseDate once the weekday is removed and zone names are replaced by offsets
+var dateFormats = []string{
+	time.RFC3339Nano,
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04",
+	"2 Jan 06 15:04:05",
+	"2 Jan 06 15:04",
+	"2 Jan 2006",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+}
+
+// zoneOffsets maps the zone names found in feeds to their offsets, Go would read unknown names as UTC
+var zoneOffsets = map[string]string{
+	"UT": "+0000", "UTC": "+0000", "GMT": "+0000", "Z": "+0000",
+	"EST": "-0500", "EDT": "-0400", "CST": "-0600", "CDT": "-0500",
+	"MST": "-0700", "MDT": "-0600", "PST": "-0800", "PDT": "-0700",
+	"WET": "+0000", "WEST": "+0100", "BST": "+0100", "CET": "+0100", "CEST": "+0200",
+	"EET": "+0200", "EEST": "+0300", "MSK": "+0300", "IST": "+0530", "JST": "+0900",
+	"AEST": "+1000", "AEDT": "+1100",
+}
+
+var (
+	dateWeekdayRe = regexp.MustCompile(`^[A-Za-z]+,?\s+`)
+	dateZoneRe    = regexp.MustCompile(`\s([A-Z]{1,4})$`)
+	dateCommentRe = regexp.MustCompile(`\s*\([^)]*\)$`)
+	dateSpaceRe   = regexp.MustCompile(`\s+`)
+)
+
+// parseDate reads the dates found in feeds and attributes: RFC3339, RFC1123 and RFC822 with or without
+// weekday, seconds or zone, two digit years, zone names, plain dates and Unix timestamps.
+// Dates without a zone are UTC.
+func parseDate(date string) (time.Time, error) {
+	value := strings.TrimSpace(date)
+	if value == "" {
+		return time.Time{}, errors.New("empty date")
+	}
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return parseNumericDate(date, value, unix)
+	}
+
+	value = dateSpaceRe.ReplaceAllString(value, " ")
+	if value = dateCommentRe.ReplaceAllString(value, ""); value == "" {
+		return time.Time{}, errors.Errorf("failed to parse date %q", date)
+	}
+	if !strings.ContainsAny(value[:1], "0123456789") {
+		if value = dateWeekdayRe.ReplaceAllString(value, ""); value == "" {
+			return time.Time{}, errors.Errorf("failed to parse date %q", date)
+		}
+	}
+	if m := dateZoneRe.FindStringSubmatch(value); m != nil {
+		if offset, ok := zoneOffsets[m[1]]; ok {
+			value = value[:len(value)-len(m[1])] + offset
+		}
+	}
+	for _, format := range dateFormats {
+		if parsed, err := time.Parse(format, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("failed to parse date %q", date)
+}
+
+// Unix timestamps outside of these bounds are more likely to be something else, like a year
+const (
+	minUnixSeconds = 1e8  // 1973
+	maxUnixSeconds = 1e10 // 2286
+)
+
+// parseNumericDate reads an all digit date as 20060102, Unix seconds or Unix milliseconds
+func parseNumericDate(date string, value string, number int64) (time.Time, error) {
+	if len(value) == 8 {
+		if parsed, err := time.Parse("20060102", value); err == nil {
+			return parsed, nil
+		}
+	}
+	switch {
+	case number >= minUnixSeconds && number < maxUnixSeconds:
+		return time.Unix(number, 0).UTC(), nil
+	case number >= minUnixSeconds*1000 && number < maxUnixSeconds*1000:
+		return time.Unix(0, number*int64(time.Millisecond)).UTC(), nil
+	}
+	return time.Time{}, errors.Errorf("failed to parse date %q, not a date or timestamp", date)
+}
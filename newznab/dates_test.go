@@ -0,0 +1,74 @@
+package newznab
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDate(t *testing.T) {
+	want := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+	for _, date := range []string{
+		"Mon, 02 Jan 2006 15:04:05 +0000",
+		"Mon, 2 Jan 2006 15:04:05 GMT",
+		"Mon, 2 Jan 2006 15:04:05 UT",
+		"Monday, 02 Jan 2006 15:04:05 +0000",
+		"02 Jan 2006 15:04:05 +0000",
+		"Mon, 02 Jan 06 15:04:05 +0000",
+		"Mon, 02 Jan 2006 10:04:05 EST",
+		"Mon, 02 Jan 2006 07:04:05 PST",
+		"Mon, 02 Jan 2006 16:04:05 CET",
+		"Mon, 02 Jan 2006 15:04:05 +0000 (UTC)",
+		"  Mon,  02 Jan 2006\t15:04:05 +0000 ",
+		"2006-01-02T15:04:05Z",
+		"2006-01-02T17:04:05+02:00",
+		"2006-01-02T15:04:05.000Z",
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		"1136214245",
+		"1136214245000",
+	} {
+		parsed, err := parseDate(date)
+		require.NoError(t, err, date)
+		require.True(t, want.Equal(parsed), "%s parsed as %s", date, parsed)
+	}
+
+	t.Run("dates", func(t *testing.T) {
+		for _, date := range []string{"2006-01-02", "2 Jan 2006", "Mon, 02 Jan 2006", "2006/01/02", "20060102"} {
+			parsed, err := parseDate(date)
+			require.NoError(t, err, date)
+			require.Equal(t, time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC), parsed, date)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, date := range []string{"", "yesterday", "Mon, 32 Jan 2006 15:04:05 +0000", "2006-13-02",
+			"(GMT)", " (UTC) ", "Mon,", "2017", "0", "12345", "99999999999999"} {
+			_, err := parseDate(date)
+			require.Error(t, err, date)
+		}
+	})
+}
+
+func TestUnmarshalBadDate(t *testing.T) {
+	const feed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/"><channel>
+<item><title>good</title><pubDate>Mon, 2 Jan 2006 15:04:05 GMT</pubDate></item>
+<item><title>bad</title><pubDate>sometime last week</pubDate></item>
+<item><title>empty</title><pubDate></pubDate></item>
+<item><title>comment</title><pubDate>(GMT)</pubDate></item>
+</channel></rss>`
+	var resp SearchResponse
+	require.NoError(t, xml.Unmarshal([]byte(feed), &resp))
+	require.Len(t, resp.Channel.NZBs, 4)
+	require.True(t, time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC).Equal(resp.Channel.NZBs[0].Date.Time))
+	require.True(t, resp.Channel.NZBs[1].Date.IsZero())
+	require.True(t, resp.Channel.NZBs[2].Date.IsZero())
+	require.True(t, resp.Channel.NZBs[3].Date.IsZero())
+
+	var date Time
+	require.NoError(t, xml.Unmarshal([]byte(`<pubDate>(GMT)</pubDate>`), &date))
+	require.True(t, date.IsZero())
+}
@@ -0,0 +1,195 @@
+package newznab
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Preference is a criterion used to pick the preferred copy out of a group of duplicates
+type Preference int
+
+// Criteria for picking the preferred copy of a release
+const (
+	// PreferIndexerPriority prefers copies from indexers listed first in DedupeOptions.IndexerPriority
+	PreferIndexerPriority Preference = iota
+	// PreferMostGrabs prefers copies with the most grabs
+	PreferMostGrabs
+	// PreferOldest prefers the copy that was posted first
+	PreferOldest
+	// PreferNewest prefers the copy that was posted last
+	PreferNewest
+)
+
+// DedupeOptions controls how duplicates are detected and which copy is preferred
+type DedupeOptions struct {
+	// SizeTolerance is the maximum relative size difference between duplicates, e.g. 0.01 for 1%
+	SizeTolerance float64
+	// DateTolerance is the maximum difference between the usenet (or publish) dates of duplicates
+	DateTolerance time.Duration
+	// IndexerPriority lists indexer names (NZB.SourceName) from most to least preferred
+	IndexerPriority []string
+	// Prefer lists the criteria used to pick the preferred copy, in order
+	Prefer []Preference
+}
+
+// DefaultDedupeOptions are sensible defaults for cross-indexer duplicate detection
+var DefaultDedupeOptions = DedupeOptions{
+	SizeTolerance: 0.01,
+	DateTolerance: 24 * time.Hour,
+	Prefer:        []Preference{PreferIndexerPriority, PreferMostGrabs, PreferOldest},
+}
+
+// DuplicateGroup is a set of NZBs that most likely are the same release
+type DuplicateGroup struct {
+	// Preferred is the copy to grab first
+	Preferred NZB `json:"preferred"`
+	// Alternates are the other copies in order of preference, useful for failover
+	Alternates []NZB `json:"alternates,omitempty"`
+}
+
+// All returns the preferred copy followed by all alternates
+func (g DuplicateGroup) All() []NZB {
+	return append([]NZB{g.Preferred}, g.Alternates...)
+}
+
+// Dedupe groups likely duplicates in the given NZBs.
+// Groups are returned in the order their first member appears in nzbs.
+func Dedupe(nzbs []NZB, opts DedupeOptions) []DuplicateGroup {
+	parent := make([]int, len(nzbs))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(a, b int) {
+		ra, rb := find(a), find(b)
+		if ra < rb {
+			parent[rb] = ra
+		} else if rb < ra {
+			parent[ra] = rb
+		}
+	}
+
+	byHash := map[string]int{}
+	byTitle := map[string][]int{}
+	for i, nzb := range nzbs {
+		if hash := strings.ToLower(nzb.InfoHash); hash != "" {
+			if first, ok := byHash[hash]; ok {
+				union(first, i)
+			} else {
+				byHash[hash] = i
+			}
+		}
+		title := NormalizeTitle(nzb.Title)
+		for _, other := range byTitle[title] {
+			if opts.isDuplicate(nzbs[other], nzb) {
+				union(other, i)
+			}
+		}
+		byTitle[title] = append(byTitle[title], i)
+	}
+
+	var order []int
+	members := map[int][]NZB{}
+	for i, nzb := range nzbs {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			order = append(order, root)
+		}
+		members[root] = append(members[root], nzb)
+	}
+
+	groups := make([]DuplicateGroup, 0, len(order))
+	for _, root := range order {
+		copies := members[root]
+		sort.SliceStable(copies, func(a, b int) bool {
+			return opts.prefers(copies[a], copies[b])
+		})
+		groups = append(groups, DuplicateGroup{
+			Preferred:  copies[0],
+			Alternates: copies[1:],
+		})
+	}
+	return groups
+}
+
+// NormalizeTitle returns a release title reduced to lowercase words for comparison,
+// so "Bones.S10E22.DVDRip.X264-REWARD" and "Bones S10E22 DVDRip x264 REWARD" are equal
+func NormalizeTitle(title string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+func (o DedupeOptions) isDuplicate(a, b NZB) bool {
+	// Torrents with different info hashes are different releases even if they share a title
+	if a.InfoHash != "" && b.InfoHash != "" && !strings.EqualFold(a.InfoHash, b.InfoHash) {
+		return false
+	}
+	if a.Size > 0 && b.Size > 0 {
+		larger, smaller := float64(a.Size), float64(b.Size)
+		if smaller > larger {
+			larger, smaller = smaller, larger
+		}
+		if (larger-smaller)/larger > o.SizeTolerance {
+			return false
+		}
+	}
+	dateA, dateB := postDate(a), postDate(b)
+	if o.DateTolerance > 0 && !dateA.IsZero() && !dateB.IsZero() {
+		diff := dateA.Sub(dateB)
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > o.DateTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// prefers reports whether a should be preferred over b
+func (o DedupeOptions) prefers(a, b NZB) bool {
+	for _, preference := range o.Prefer {
+		switch preference {
+		case PreferIndexerPriority:
+			pa, pb := o.priority(a), o.priority(b)
+			if pa != pb {
+				return pa < pb
+			}
+		case PreferMostGrabs:
+			if a.NumGrabs != b.NumGrabs {
+				return a.NumGrabs > b.NumGrabs
+			}
+		case PreferOldest, PreferNewest:
+			dateA, dateB := postDate(a), postDate(b)
+			if !dateA.IsZero() && !dateB.IsZero() && !dateA.Equal(dateB) {
+				return dateA.Before(dateB) == (preference == PreferOldest)
+			}
+		}
+	}
+	return false
+}
+
+func (o DedupeOptions) priority(nzb NZB) int {
+	for i, name := range o.IndexerPriority {
+		if name == nzb.SourceName {
+			return i
+		}
+	}
+	return len(o.IndexerPriority)
+}
+
+func postDate(nzb NZB) time.Time {
+	if !nzb.UsenetDate.IsZero() {
+		return nzb.UsenetDate
+	}
+	return nzb.PubDate
+}
@@ -0,0 +1,57 @@
+package newznab
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupe(t *testing.T) {
+	posted := time.Date(2015, 10, 1, 22, 53, 10, 0, time.UTC)
+	nzbs := []NZB{
+		{ID: "a1", SourceName: "a", Title: "Bones.S10E22.DVDRip.X264-REWARD", Size: 460000000, UsenetDate: posted, NumGrabs: 10},
+		{ID: "b1", SourceName: "b", Title: "Bones S10E22 DVDRip x264-REWARD", Size: 461000000, UsenetDate: posted.Add(time.Hour), NumGrabs: 50},
+		{ID: "b2", SourceName: "b", Title: "Bones.S10E22.DVDRip.X264-REWARD", Size: 900000000, UsenetDate: posted},
+		{ID: "c1", SourceName: "c", Title: "Bones.S10E22.DVDRip.X264-REWARD", Size: 460000000, UsenetDate: posted.Add(72 * time.Hour)},
+		{ID: "t1", SourceName: "a", Title: "Oldboy.2003.1080p", InfoHash: "ABCDEF"},
+		{ID: "t2", SourceName: "c", Title: "Oldboy 2003 1080p BluRay", InfoHash: "abcdef"},
+		{ID: "t3", SourceName: "c", Title: "Oldboy.2003.1080p", InfoHash: "123456"},
+	}
+
+	t.Run("normalized title", func(t *testing.T) {
+		require.Equal(t, "bones s10e22 dvdrip x264 reward", NormalizeTitle(nzbs[0].Title))
+		require.Equal(t, NormalizeTitle(nzbs[0].Title), NormalizeTitle(nzbs[1].Title))
+	})
+
+	t.Run("grouping", func(t *testing.T) {
+		groups := Dedupe(nzbs, DefaultDedupeOptions)
+		require.Len(t, groups, 5)
+		require.Len(t, groups[0].All(), 2)
+		require.Len(t, groups[1].All(), 1)
+		require.Len(t, groups[2].All(), 1)
+		require.Len(t, groups[3].All(), 2)
+		require.Len(t, groups[4].All(), 1)
+	})
+
+	t.Run("prefer most grabs", func(t *testing.T) {
+		groups := Dedupe(nzbs, DefaultDedupeOptions)
+		require.Equal(t, "b1", groups[0].Preferred.ID)
+		require.Equal(t, "a1", groups[0].Alternates[0].ID)
+	})
+
+	t.Run("prefer indexer priority", func(t *testing.T) {
+		opts := DefaultDedupeOptions
+		opts.IndexerPriority = []string{"a", "b"}
+		groups := Dedupe(nzbs, opts)
+		require.Equal(t, "a1", groups[0].Preferred.ID)
+		require.Equal(t, "t1", groups[3].Preferred.ID)
+	})
+
+	t.Run("prefer newest", func(t *testing.T) {
+		opts := DefaultDedupeOptions
+		opts.Prefer = []Preference{PreferNewest}
+		groups := Dedupe(nzbs, opts)
+		require.Equal(t, "b1", groups[0].Preferred.ID)
+	})
+}
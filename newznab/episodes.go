@@ -0,0 +1,159 @@
+package newznab
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// maxEpisodeRange limits how many episodes a range like "E01-E24" expands to
+const maxEpisodeRange = 100
+
+var (
+	seasonRe   = regexp.MustCompile(`(?i)^s?(\d{1,4})$`)
+	episodesRe = regexp.MustCompile(`(?i)^e?\d{1,4}(?:\s*(?:-|&|,|e|-e)\s*\d{1,4})*$`)
+	numberRe   = regexp.MustCompile(`\d+`)
+	dayRe      = regexp.MustCompile(`^(\d{1,2})[/.-](\d{1,2})$`)
+	airDateRe  = regexp.MustCompile(`^(\d{4})[/.-](\d{1,2})[/.-](\d{1,2})$`)
+)
+
+// ForSeason returns a copy of this request for a whole season, without an episode
+func (r SearchRequest) ForSeason(season int) SearchRequest {
+	r.tvSearch()
+	r.Season = strconv.Itoa(season)
+	r.Episode = ""
+	return r
+}
+
+// ForEpisode returns a copy of this request for a single episode of a season
+func (r SearchRequest) ForEpisode(season int, episode int) SearchRequest {
+	r.tvSearch()
+	r.Season = strconv.Itoa(season)
+	r.Episode = strconv.Itoa(episode)
+	return r
+}
+
+// ForDailyEpisode returns a copy of this request for the episode of a daily show aired on the given date,
+// sent as season=2017&ep=03/05
+func (r SearchRequest) ForDailyEpisode(airDate time.Time) SearchRequest {
+	r.tvSearch()
+	r.Season = strconv.Itoa(airDate.Year())
+	r.Episode = airDate.Format("01/02")
+	return r
+}
+
+// ForAbsoluteEpisode returns a copy of this request for an episode numbered across all seasons, as anime
+// usually is, sent as ep without a season
+func (r SearchRequest) ForAbsoluteEpisode(episode int) SearchRequest {
+	r.tvSearch()
+	r.Season = ""
+	r.Episode = strconv.Itoa(episode)
+	return r
+}
+
+func (r *SearchRequest) tvSearch() {
+	if r.Type == "" {
+		r.Type = "tvsearch"
+	}
+}
+
+// parseEpisode sets the numbered episode fields of the NZB from its season and episode attributes.
+// A season that is a year with an episode like "03/05", or an episode like "2017/03/05", is a daily show,
+// an episode without a season is an absolute episode.
+func (p *attrParser) parseEpisode(nzb *NZB) {
+	season := strings.TrimSpace(nzb.Season)
+	episode := strings.TrimSpace(nzb.Episode)
+	if season == "" && episode == "" {
+		return
+	}
+
+	if m := airDateRe.FindStringSubmatch(episode); m != nil {
+		p.setAirDateEpisode(nzb, m[1], m[2], m[3])
+		return
+	}
+	if m := dayRe.FindStringSubmatch(episode); m != nil && len(season) == 4 {
+		p.setAirDateEpisode(nzb, season, m[1], m[2])
+		return
+	}
+
+	if season != "" {
+		m := seasonRe.FindStringSubmatch(season)
+		if m == nil {
+			p.warn(Attribute{Name: "season", Value: nzb.Season}, errors.New("not a season number"))
+		} else {
+			nzb.SeasonNumber, _ = strconv.Atoi(m[1])
+		}
+	}
+	if episode == "" {
+		return
+	}
+	episodes, err := parseEpisodeNumbers(episode)
+	if err != nil {
+		p.warn(Attribute{Name: "episode", Value: nzb.Episode}, err)
+		return
+	}
+	if season == "" && len(episodes) == 1 {
+		nzb.AbsoluteEpisode = episodes[0]
+		return
+	}
+	nzb.EpisodeNumbers = episodes
+}
+
+func (p *attrParser) setAirDateEpisode(nzb *NZB, year, month, day string) {
+	y, _ := strconv.Atoi(year)
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	date := time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+	if date.Month() != time.Month(m) || date.Day() != d {
+		p.warn(Attribute{Name: "episode", Value: nzb.Episode}, errors.New("invalid air date"))
+		return
+	}
+	nzb.AirDateEpisode = date
+}
+
+// parseEpisodeNumbers reads episode lists like "E01", "1", "E01E02" and ranges like "E01-E03" or "1-3"
+func parseEpisodeNumbers(value string) ([]int, error) {
+	if !episodesRe.MatchString(value) {
+		return nil, errors.New("not an episode number")
+	}
+	var episodes []int
+	for _, number := range numberRe.FindAllString(value, -1) {
+		episode, _ := strconv.Atoi(number)
+		episodes = append(episodes, episode)
+	}
+	if len(episodes) == 2 && strings.Contains(value, "-") {
+		first, last := episodes[0], episodes[1]
+		if last < first || last-first >= maxEpisodeRange {
+			return nil, errors.Errorf("invalid episode range %d-%d", first, last)
+		}
+		episodes = episodes[:0]
+		for episode := first; episode <= last; episode++ {
+			episodes = append(episodes, episode)
+		}
+	}
+	return episodes, nil
+}
+
+// episodeAttrs returns the season and episode attributes of the NZB, formatted from the numbered fields
+// when the attributes weren't set
+func (n NZB) episodeAttrs() (string, string) {
+	season, episode := n.Season, n.Episode
+	switch {
+	case season != "" || episode != "":
+	case !n.AirDateEpisode.IsZero():
+		season = strconv.Itoa(n.AirDateEpisode.Year())
+		episode = n.AirDateEpisode.Format("01/02")
+	case n.AbsoluteEpisode > 0:
+		episode = strconv.Itoa(n.AbsoluteEpisode)
+	case n.SeasonNumber > 0:
+		season = fmt.Sprintf("S%02d", n.SeasonNumber)
+		for _, number := range n.EpisodeNumbers {
+			episode += fmt.Sprintf("E%02d", number)
+		}
+	}
+	return season, episode
+}
@@ -0,0 +1,136 @@
+package newznab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEpisode(t *testing.T) {
+	parse := func(season, episode string) (NZB, []ParseWarning) {
+		nzb := NZB{Season: season, Episode: episode}
+		var parser attrParser
+		parser.parseEpisode(&nzb)
+		return nzb, parser.warnings
+	}
+
+	t.Run("seasons and episodes", func(t *testing.T) {
+		for _, tc := range []struct {
+			season, episode string
+			wantSeason      int
+			wantEpisodes    []int
+		}{
+			{"S10", "E01", 10, []int{1}},
+			{"10", "1", 10, []int{1}},
+			{"s01", "e22", 1, []int{22}},
+			{"1", "E01E02", 1, []int{1, 2}},
+			{"1", "E01-E03", 1, []int{1, 2, 3}},
+			{"1", "1-3", 1, []int{1, 2, 3}},
+			{"S03", "", 3, nil},
+			{"2017", "E05", 2017, []int{5}},
+		} {
+			nzb, warnings := parse(tc.season, tc.episode)
+			require.Empty(t, warnings)
+			require.Equal(t, tc.wantSeason, nzb.SeasonNumber, tc.season)
+			require.Equal(t, tc.wantEpisodes, nzb.EpisodeNumbers, tc.episode)
+			require.Zero(t, nzb.AbsoluteEpisode)
+			require.True(t, nzb.AirDateEpisode.IsZero())
+		}
+	})
+
+	t.Run("daily", func(t *testing.T) {
+		want := time.Date(2017, 3, 5, 0, 0, 0, 0, time.UTC)
+		for _, tc := range [][2]string{{"2017", "03/05"}, {"2017", "3/5"}, {"", "2017/03/05"}, {"2017", "2017-03-05"}} {
+			nzb, warnings := parse(tc[0], tc[1])
+			require.Empty(t, warnings)
+			require.Equal(t, want, nzb.AirDateEpisode, tc)
+			require.Zero(t, nzb.SeasonNumber)
+			require.Empty(t, nzb.EpisodeNumbers)
+		}
+	})
+
+	t.Run("absolute", func(t *testing.T) {
+		nzb, warnings := parse("", "1071")
+		require.Empty(t, warnings)
+		require.Equal(t, 1071, nzb.AbsoluteEpisode)
+		require.Empty(t, nzb.EpisodeNumbers)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, tc := range [][2]string{{"Season One", ""}, {"1", "Pilot"}, {"1", "E05-E02"}, {"2017", "02/30"}} {
+			_, warnings := parse(tc[0], tc[1])
+			require.Len(t, warnings, 1, tc)
+		}
+	})
+
+	t.Run("attrs round trip", func(t *testing.T) {
+		for _, nzb := range []NZB{
+			{SeasonNumber: 1, EpisodeNumbers: []int{1, 2}},
+			{AirDateEpisode: time.Date(2017, 3, 5, 0, 0, 0, 0, time.UTC)},
+			{AbsoluteEpisode: 1071},
+		} {
+			season, episode := nzb.episodeAttrs()
+			parsed, warnings := parse(season, episode)
+			require.Empty(t, warnings)
+			require.Equal(t, nzb.SeasonNumber, parsed.SeasonNumber)
+			require.Equal(t, nzb.EpisodeNumbers, parsed.EpisodeNumbers)
+			require.Equal(t, nzb.AbsoluteEpisode, parsed.AbsoluteEpisode)
+			require.Equal(t, nzb.AirDateEpisode, parsed.AirDateEpisode)
+		}
+	})
+}
+
+func TestEpisodeSearches(t *testing.T) {
+	var got url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query()
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel></channel></rss>`)) // nolint:errcheck
+	}))
+	defer ts.Close()
+	client := New(ts.URL, "gibberish", 1234, false)
+
+	t.Run("requests", func(t *testing.T) {
+		for _, tc := range []struct {
+			req           SearchRequest
+			season, ep, t string
+		}{
+			{SearchRequest{TVDBID: 1}.ForSeason(3), "3", "", "tvsearch"},
+			{SearchRequest{TVDBID: 1}.ForEpisode(3, 4), "3", "4", "tvsearch"},
+			{SearchRequest{Query: "daily show"}.ForDailyEpisode(time.Date(2017, 3, 5, 0, 0, 0, 0, time.UTC)), "2017", "03/05", "tvsearch"},
+			{SearchRequest{Type: "search", Query: "one piece", Season: "1"}.ForAbsoluteEpisode(1071), "", "1071", "search"},
+		} {
+			_, err := client.Search(context.Background(), tc.req)
+			require.NoError(t, err)
+			require.Equal(t, tc.t, got.Get("t"))
+			require.Equal(t, tc.season, got.Get("season"))
+			require.Equal(t, tc.ep, got.Get("ep"))
+		}
+	})
+
+	t.Run("helpers", func(t *testing.T) {
+		_, err := client.SearchWithTVDB(nil, 75682, 0, 3)
+		require.NoError(t, err)
+		require.Equal(t, "0", got.Get("season"), "season 0 holds the specials")
+		require.Equal(t, "3", got.Get("ep"))
+		_, hasEpisode := got["episode"]
+		require.False(t, hasEpisode)
+
+		_, err = client.SearchWithTVDB(nil, 75682, 3, 0)
+		require.NoError(t, err)
+		require.Equal(t, "3", got.Get("season"))
+		_, hasEpisode = got["ep"]
+		require.False(t, hasEpisode, "an episode of 0 searches the whole season")
+
+		got = nil
+		results := NewAggregator(time.Second, Indexer{Name: "indexer", Client: client}).SearchWithTVDB(context.Background(), nil, 75682, 3, 0)
+		require.NoError(t, results.Reports[0].Err)
+		require.Equal(t, "3", got.Get("season"))
+		_, hasEpisode = got["ep"]
+		require.False(t, hasEpisode)
+	})
+}
@@ -0,0 +1,63 @@
+package newznab
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// Error codes defined by the newznab api
+const (
+	ErrorIncorrectCredentials   = 100
+	ErrorAccountSuspended       = 101
+	ErrorInsufficientPrivileges = 102
+	ErrorRegistrationDenied     = 103
+	ErrorRegistrationClosed     = 104
+	ErrorEmailTaken             = 105
+	ErrorEmailInvalid           = 106
+	ErrorRegistrationFailed     = 107
+	ErrorMissingParameter       = 200
+	ErrorIncorrectParameter     = 201
+	ErrorNoSuchFunction         = 202
+	ErrorFunctionNotAvailable   = 203
+	ErrorNoSuchItem             = 300
+	ErrorItemAlreadyExists      = 310
+	ErrorRequestLimitReached    = 500
+	ErrorDownloadLimitReached   = 501
+	ErrorUnknown                = 900
+)
+
+// APIError is an <error> response returned by a newznab api
+type APIError struct {
+	XMLName     xml.Name `xml:"error"`
+	Code        int      `xml:"code,attr"`
+	Description string   `xml:"description,attr"`
+}
+
+// NewAPIError returns an APIError with the given code and its standard description
+func NewAPIError(code int) *APIError {
+	return &APIError{Code: code, Description: errorDescriptions[code]}
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("newznab api error %d: %s", e.Code, e.Description)
+}
+
+var errorDescriptions = map[int]string{
+	ErrorIncorrectCredentials:   "Incorrect user credentials",
+	ErrorAccountSuspended:       "Account suspended",
+	ErrorInsufficientPrivileges: "Insufficient privileges/not authorized",
+	ErrorRegistrationDenied:     "Registration denied",
+	ErrorRegistrationClosed:     "Registrations are closed",
+	ErrorEmailTaken:             "Invalid registration (Email Address Taken)",
+	ErrorEmailInvalid:           "Invalid registration (Email Address Bad Format)",
+	ErrorRegistrationFailed:     "Registration Failed (Data error)",
+	ErrorMissingParameter:       "Missing parameter",
+	ErrorIncorrectParameter:     "Incorrect parameter",
+	ErrorNoSuchFunction:         "No such function",
+	ErrorFunctionNotAvailable:   "Function not available",
+	ErrorNoSuchItem:             "No such item",
+	ErrorItemAlreadyExists:      "Item already exists",
+	ErrorRequestLimitReached:    "Request limit reached",
+	ErrorDownloadLimitReached:   "Download limit reached",
+	ErrorUnknown:                "Unknown error",
+}
@@ -0,0 +1,219 @@
+package newznab
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var imdbIDRe = regexp.MustCompile(`(?i)^(?:.*imdb\.com/title/)?(?:tt)?(\d{1,10})/?$`)
+
+// MediaIDs are the ids of a show or movie on the metadata sites, zero when unknown
+type MediaIDs struct {
+	// IMDB is canonical with the "tt" prefix and at least 7 digits, like "tt0364569"
+	IMDB   string `json:"imdb,omitempty"`
+	TMDB   int    `json:"tmdb,omitempty"`
+	TVDB   int    `json:"tvdb,omitempty"`
+	TVRage int    `json:"tvrage,omitempty"`
+	TVMaze int    `json:"tvmaze,omitempty"`
+	Trakt  int    `json:"trakt,omitempty"`
+	Douban int    `json:"douban,omitempty"`
+}
+
+// NormalizeIMDBID returns the canonical form of an IMDb id given as "tt0364569", "0364569", "364569"
+// or an imdb.com title URL
+func NormalizeIMDBID(id string) (string, error) {
+	m := imdbIDRe.FindStringSubmatch(strings.TrimSpace(id))
+	if m == nil {
+		return "", errors.Errorf("invalid imdb id %q", id)
+	}
+	number := strings.TrimLeft(m[1], "0")
+	if number == "" {
+		return "", errors.Errorf("invalid imdb id %q", id)
+	}
+	if len(number) < 7 {
+		number = strings.Repeat("0", 7-len(number)) + number
+	}
+	return "tt" + number, nil
+}
+
+// IsZero reports whether no id is set
+func (m MediaIDs) IsZero() bool {
+	return m == MediaIDs{}
+}
+
+// IMDBNumber returns the IMDb id without the "tt" prefix, the format of the newznab imdbid parameter and imdb attribute
+func (m MediaIDs) IMDBNumber() string {
+	return strings.TrimPrefix(m.IMDB, "tt")
+}
+
+// Merge returns these ids with the ids that aren't set taken from other
+func (m MediaIDs) Merge(other MediaIDs) MediaIDs {
+	if m.IMDB == "" {
+		m.IMDB = other.IMDB
+	}
+	mergeInt := func(id *int, other int) {
+		if *id == 0 {
+			*id = other
+		}
+	}
+	mergeInt(&m.TMDB, other.TMDB)
+	mergeInt(&m.TVDB, other.TVDB)
+	mergeInt(&m.TVRage, other.TVRage)
+	mergeInt(&m.TVMaze, other.TVMaze)
+	mergeInt(&m.Trakt, other.Trakt)
+	mergeInt(&m.Douban, other.Douban)
+	return m
+}
+
+// imdbParam returns the imdbid search parameter for the given id, ids that can't be normalized are sent as is
+func imdbParam(id string) string {
+	if imdb, err := NormalizeIMDBID(id); err == nil {
+		return strings.TrimPrefix(imdb, "tt")
+	}
+	return id
+}
+
+// params returns the ids as newznab search parameters
+func (m MediaIDs) params() url.Values {
+	vals := url.Values{}
+	if m.IMDB != "" {
+		vals.Set("imdbid", imdbParam(m.IMDB))
+	}
+	setInt := func(key string, id int) {
+		if id != 0 {
+			vals.Set(key, strconv.Itoa(id))
+		}
+	}
+	setInt("tmdbid", m.TMDB)
+	setInt("tvdbid", m.TVDB)
+	setInt("rid", m.TVRage)
+	setInt("tvmazeid", m.TVMaze)
+	setInt("traktid", m.Trakt)
+	setInt("doubanid", m.Douban)
+	return vals
+}
+
+// ParseMediaIDs reads ids from search parameters or attributes, the imdb id is normalized.
+// Ids that can't be parsed are left zero.
+func ParseMediaIDs(vals url.Values) MediaIDs {
+	var ids MediaIDs
+	for _, key := range []string{"imdbid", "imdb"} {
+		if imdb, err := NormalizeIMDBID(vals.Get(key)); err == nil {
+			ids.IMDB = imdb
+			break
+		}
+	}
+	getInt := func(keys ...string) int {
+		for _, key := range keys {
+			if id, err := strconv.Atoi(strings.TrimSpace(vals.Get(key))); err == nil {
+				return id
+			}
+		}
+		return 0
+	}
+	ids.TMDB = getInt("tmdbid")
+	ids.TVDB = getInt("tvdbid")
+	ids.TVRage = getInt("rid", "rageid", "tvrageid")
+	ids.TVMaze = getInt("tvmazeid")
+	ids.Trakt = getInt("traktid")
+	ids.Douban = getInt("doubanid")
+	return ids
+}
+
+// MediaIDs returns the ids of the request, the single id fields take precedence over IDs
+func (r SearchRequest) MediaIDs() MediaIDs {
+	ids := MediaIDs{
+		TVRage: r.TVRageID,
+		TVDB:   r.TVDBID,
+		TVMaze: r.TVMazeID,
+	}
+	if r.IMDBID != "" {
+		ids.IMDB, _ = NormalizeIMDBID(r.IMDBID)
+	}
+	return ids.Merge(r.IDs)
+}
+
+// ForCapabilities returns a copy of this request that only sends the ids the indexer lists in the
+// supported params of the search type, in the newznab format. Requests without a type are sent as t=search.
+func (r SearchRequest) ForCapabilities(caps Capabilities) SearchRequest {
+	searchType := r.Type
+	if searchType == "" {
+		searchType = "search"
+	}
+	ids := r.MediaIDs()
+	supported := MediaIDs{}
+	for key := range ids.params() {
+		if !caps.Supports(searchType, key) {
+			continue
+		}
+		switch key {
+		case "imdbid":
+			supported.IMDB = ids.IMDB
+		case "tmdbid":
+			supported.TMDB = ids.TMDB
+		case "tvdbid":
+			supported.TVDB = ids.TVDB
+		case "rid":
+			supported.TVRage = ids.TVRage
+		case "tvmazeid":
+			supported.TVMaze = ids.TVMaze
+		case "traktid":
+			supported.Trakt = ids.Trakt
+		case "doubanid":
+			supported.Douban = ids.Douban
+		}
+	}
+	r.TVRageID, r.TVDBID, r.TVMazeID, r.IMDBID = 0, 0, 0, ""
+	r.IDs = supported
+	return r
+}
+
+// parseIDs sets the MediaIDs of the NZB from its id attributes
+func (p *attrParser) parseIDs(nzb *NZB) {
+	if nzb.IMDBID != "" {
+		if imdb, err := NormalizeIMDBID(nzb.IMDBID); err != nil {
+			p.warn(Attribute{Name: "imdb", Value: nzb.IMDBID}, err)
+		} else {
+			nzb.MediaIDs.IMDB = imdb
+		}
+	}
+	nzb.MediaIDs.TVDB = int(p.int(Attribute{Name: "tvdbid", Value: nzb.TVDBID}, 32))
+	nzb.MediaIDs.TVRage = int(p.int(Attribute{Name: "rageid", Value: nzb.TVRageID}, 32))
+	nzb.MediaIDs.TVMaze = int(p.int(Attribute{Name: "tvmazeid", Value: nzb.TVMazeID}, 32))
+}
+
+// SyncMediaIDs sets the MediaIDs of the NZB that are missing from its id fields, and the id fields
+// that are missing from its MediaIDs. Call it after setting either by hand.
+func (n *NZB) SyncMediaIDs() {
+	if n.MediaIDs.IMDB == "" {
+		if imdb, err := NormalizeIMDBID(n.IMDBID); err == nil {
+			n.MediaIDs.IMDB = imdb
+		}
+	}
+	if n.IMDBID == "" {
+		n.IMDBID = n.MediaIDs.IMDBNumber()
+	}
+	syncID := func(field *string, id *int) {
+		if *id == 0 {
+			*id, _ = strconv.Atoi(strings.TrimSpace(*field))
+		}
+		if *field == "" && *id != 0 {
+			*field = strconv.Itoa(*id)
+		}
+	}
+	syncID(&n.TVDBID, &n.MediaIDs.TVDB)
+	syncID(&n.TVRageID, &n.MediaIDs.TVRage)
+	syncID(&n.TVMazeID, &n.MediaIDs.TVMaze)
+}
+
+// idAttr returns an id attribute, formatted from the MediaIDs when the string field isn't set
+func idAttr(value string, id int) string {
+	if value == "" && id != 0 {
+		return strconv.Itoa(id)
+	}
+	return value
+}
@@ -0,0 +1,137 @@
+package newznab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeIMDBID(t *testing.T) {
+	for _, id := range []string{"tt0364569", "TT0364569", "0364569", "364569", " tt364569 ", "https://www.imdb.com/title/tt0364569/"} {
+		normalized, err := NormalizeIMDBID(id)
+		require.NoError(t, err, id)
+		require.Equal(t, "tt0364569", normalized, id)
+	}
+	normalized, err := NormalizeIMDBID("tt10872600")
+	require.NoError(t, err)
+	require.Equal(t, "tt10872600", normalized)
+
+	for _, id := range []string{"", "tt", "0", "nm0000123", "tt12a"} {
+		_, err := NormalizeIMDBID(id)
+		require.Error(t, err, id)
+	}
+}
+
+func TestMediaIDs(t *testing.T) {
+	t.Run("results", func(t *testing.T) {
+		client := New("http://example.com", "gibberish", 1234, false)
+		nzb, warnings := client.parseNZB(RawNZB{Attributes: []Attribute{
+			{Name: "imdb", Value: "0364569"},
+			{Name: "tmdbid", Value: "1234"},
+			{Name: "traktid", Value: "5678"},
+			{Name: "doubanid", Value: "91011"},
+			{Name: "tvdbid", Value: "75682"},
+			{Name: "tvmazeid", Value: "65"},
+		}})
+		require.Empty(t, warnings)
+		require.Equal(t, "0364569", nzb.IMDBID)
+		require.Equal(t, MediaIDs{IMDB: "tt0364569", TMDB: 1234, Trakt: 5678, Douban: 91011, TVDB: 75682, TVMaze: 65}, nzb.MediaIDs)
+
+		raw := nzb.Raw()
+		again, _ := client.parseNZB(raw)
+		require.Equal(t, nzb.MediaIDs, again.MediaIDs)
+
+		nzb, warnings = client.parseNZB(RawNZB{Attributes: []Attribute{{Name: "imdbid", Value: "tt0364569"}, {Name: "tmdbid", Value: "abc"}}})
+		require.Equal(t, "tt0364569", nzb.MediaIDs.IMDB)
+		require.Len(t, warnings, 1)
+		require.Equal(t, "attr:tmdbid", warnings[0].Field)
+	})
+
+	t.Run("request values", func(t *testing.T) {
+		vals := SearchRequest{Type: "movie", IMDBID: "tt0364569", IDs: MediaIDs{IMDB: "tt0000001", TMDB: 1234, Trakt: 5}}.Values()
+		require.Equal(t, "0364569", vals.Get("imdbid"), "the single id field takes precedence")
+		require.Equal(t, "1234", vals.Get("tmdbid"))
+		require.Equal(t, "5", vals.Get("traktid"))
+		require.Equal(t, "0364569", SearchRequest{IDs: MediaIDs{IMDB: "364569"}}.Values().Get("imdbid"), "ids set by hand are normalized")
+
+		require.Equal(t, MediaIDs{IMDB: "tt0364569", TMDB: 12, TVRage: 3}, ParseMediaIDs(url.Values{
+			"imdbid": []string{"364569"},
+			"tmdbid": []string{"12"},
+			"rid":    []string{"3"},
+			"tvdbid": []string{"x"},
+		}))
+	})
+
+	t.Run("sync", func(t *testing.T) {
+		nzb := NZB{IMDBID: "364569", TVDBID: "75682", MediaIDs: MediaIDs{TVMaze: 65, TVRage: 3}, TVRageID: "4"}
+		nzb.SyncMediaIDs()
+		require.Equal(t, MediaIDs{IMDB: "tt0364569", TVDB: 75682, TVMaze: 65, TVRage: 3}, nzb.MediaIDs)
+		require.Equal(t, "65", nzb.TVMazeID)
+		require.Equal(t, "4", nzb.TVRageID, "set fields are kept")
+
+		nzb = NZB{MediaIDs: MediaIDs{IMDB: "tt0364569"}}
+		nzb.SyncMediaIDs()
+		require.Equal(t, "0364569", nzb.IMDBID)
+	})
+
+	t.Run("for capabilities", func(t *testing.T) {
+		var caps Capabilities
+		caps.Searching.MovieSearch.Available = "yes"
+		caps.Searching.MovieSearch.SupportedParams = "q,tmdbid"
+		req := SearchRequest{Type: "movie", IMDBID: "tt0364569", IDs: MediaIDs{TMDB: 1234, Trakt: 5}}.ForCapabilities(caps)
+		require.Equal(t, MediaIDs{TMDB: 1234}, req.IDs)
+		require.Empty(t, req.IMDBID)
+		vals := req.Values()
+		require.Empty(t, vals.Get("imdbid"))
+		require.Equal(t, "1234", vals.Get("tmdbid"))
+	})
+}
+
+func TestSearchMedia(t *testing.T) {
+	var mu sync.Mutex
+	got := map[string]url.Values{}
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			got[name] = r.URL.Query()
+			mu.Unlock()
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel></channel></rss>`)) // nolint:errcheck
+		}
+	}
+	imdbOnly := httptest.NewServer(handler("imdb"))
+	defer imdbOnly.Close()
+	tmdbOnly := httptest.NewServer(handler("tmdb"))
+	defer tmdbOnly.Close()
+	unknown := httptest.NewServer(handler("unknown"))
+	defer unknown.Close()
+	none := httptest.NewServer(handler("none"))
+	defer none.Close()
+
+	capsWith := func(params string) *Capabilities {
+		var caps Capabilities
+		caps.Searching.MovieSearch.Available = "yes"
+		caps.Searching.MovieSearch.SupportedParams = params
+		return &caps
+	}
+	agg := NewAggregator(0,
+		Indexer{Name: "imdb", Client: New(imdbOnly.URL, "gibberish", 1234, false), Capabilities: capsWith("q,imdbid")},
+		Indexer{Name: "tmdb", Client: New(tmdbOnly.URL, "gibberish", 1234, false), Capabilities: capsWith("q,tmdbid")},
+		Indexer{Name: "unknown", Client: New(unknown.URL, "gibberish", 1234, false)},
+		Indexer{Name: "none", Client: New(none.URL, "gibberish", 1234, false), Capabilities: capsWith("q")},
+	)
+	results := agg.SearchMedia(context.Background(), SearchRequest{Type: "movie", IDs: MediaIDs{IMDB: "tt0364569", TMDB: 1234}})
+	require.Equal(t, StatusSkipped, results.Reports[3].Status)
+
+	require.Equal(t, "0364569", got["imdb"].Get("imdbid"))
+	require.Empty(t, got["imdb"].Get("tmdbid"))
+	require.Equal(t, "1234", got["tmdb"].Get("tmdbid"))
+	require.Empty(t, got["tmdb"].Get("imdbid"))
+	require.Equal(t, "0364569", got["unknown"].Get("imdbid"))
+	require.Equal(t, "1234", got["unknown"].Get("tmdbid"))
+	require.NotContains(t, got, "none")
+}
@@ -0,0 +1,169 @@
+package newznab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// ResponseFormat is the format the api is asked to answer searches in
+type ResponseFormat string
+
+// Response formats supported by WithResponseFormat
+const (
+	FormatXML  ResponseFormat = "xml"
+	FormatJSON ResponseFormat = "json"
+)
+
+// WithResponseFormat returns a copy of this client that asks for search results in the given format with the o parameter.
+// JSON responses are normalized from the nZEDb, newznab+ and NNTmux dialects, when they can't be parsed XML is used instead.
+func (c Client) WithResponseFormat(format ResponseFormat) Client {
+	c.format = format
+	return c
+}
+
+// decodeJSONFeed converts an o=json search response to the SearchResponse of the XML feed.
+// The dialects differ in whether attributes are wrapped in "@attributes", whether items and
+// attrs are arrays or single objects, whether values are strings or numbers and whether the
+// items sit inside a channel.
+func decodeJSONFeed(data []byte) (SearchResponse, error) {
+	var feed SearchResponse
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var root map[string]interface{}
+	if err := dec.Decode(&root); err != nil {
+		return feed, errors.Wrap(err, "failed to unmarshal json feed")
+	}
+
+	if apiErr, ok := root["error"]; ok {
+		attrs := jsonAttributes(apiErr)
+		feed.ErrorCode, _ = strconv.Atoi(attrs["code"])
+		feed.ErrorDesc = attrs["description"]
+		if feed.ErrorCode == 0 {
+			return feed, errors.New("json error response without a code")
+		}
+		return feed, nil
+	}
+
+	channel, hasChannel := root["channel"].(map[string]interface{})
+	if !hasChannel {
+		// Some dialects put the channel fields at the top level
+		channel = root
+	}
+	items, ok := channel["item"]
+	if !ok {
+		items, ok = root["item"]
+	}
+	// A feed without results leaves out the items, anything without a channel or response isn't a feed
+	if !ok && !hasChannel && root["response"] == nil {
+		return feed, errors.New("json feed has no channel or items")
+	}
+	feed.Version = jsonAttributes(root)["version"]
+	feed.Channel.Title = jsonString(channel["title"])
+	feed.Channel.Description = jsonString(channel["description"])
+	response := jsonAttributes(channel["response"])
+	if response == nil {
+		response = jsonAttributes(root["response"])
+	}
+	feed.Channel.Response.Offset, _ = strconv.Atoi(response["offset"])
+	feed.Channel.Response.Total, _ = strconv.Atoi(response["total"])
+
+	for _, item := range jsonList(items) {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return feed, errors.Errorf("unexpected json item %v", item)
+		}
+		feed.Channel.NZBs = append(feed.Channel.NZBs, jsonItem(fields))
+	}
+	return feed, nil
+}
+
+func jsonItem(fields map[string]interface{}) RawNZB {
+	var raw RawNZB
+	raw.Title = jsonString(fields["title"])
+	raw.Link = jsonString(fields["link"])
+	raw.Comments = jsonString(fields["comments"])
+	raw.Description = jsonString(fields["description"])
+	raw.GUID.GUID = jsonString(fields["guid"])
+	raw.Category.Value = jsonString(fields["category"])
+	if size := jsonString(fields["size"]); size != "" {
+		var err error
+		if raw.Size, err = strconv.ParseInt(size, 10, 64); err != nil {
+			raw.Size = 0
+			raw.warn("size", size, err)
+		}
+	}
+	raw.setDate(jsonString(fields["pubDate"]))
+	enclosure := jsonAttributes(fields["enclosure"])
+	raw.Enclosure.URL = enclosure["url"]
+	raw.Enclosure.Length = enclosure["length"]
+	raw.Enclosure.Type = enclosure["type"]
+
+	for _, key := range []string{"attr", "newznab:attr", "torznab:attr"} {
+		for _, attr := range jsonList(fields[key]) {
+			attrs := jsonAttributes(attr)
+			if name, ok := attrs["name"]; ok {
+				raw.Attributes = append(raw.Attributes, Attribute{Name: name, Value: attrs["value"]})
+				continue
+			}
+			// A single object mapping names to values
+			for name, value := range attrs {
+				raw.Attributes = append(raw.Attributes, Attribute{Name: name, Value: value})
+			}
+		}
+	}
+	return raw
+}
+
+// jsonList returns the elements of an array, a single object is a list of one
+func jsonList(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	}
+	return []interface{}{v}
+}
+
+// jsonAttributes returns the attributes of an element, either wrapped in "@attributes" or as plain fields
+func jsonAttributes(v interface{}) map[string]string {
+	fields, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if wrapped, ok := fields["@attributes"].(map[string]interface{}); ok {
+		fields = wrapped
+	}
+	attrs := make(map[string]string, len(fields))
+	for name, value := range fields {
+		if _, nested := value.(map[string]interface{}); nested {
+			continue
+		}
+		attrs[name] = jsonString(value)
+	}
+	return attrs
+}
+
+// jsonString returns the text of a value, elements with attributes keep their text in "#text" or "text"
+func jsonString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case map[string]interface{}:
+		for _, key := range []string{"#text", "text", "_"} {
+			if text, ok := v[key]; ok {
+				return jsonString(text)
+			}
+		}
+		return ""
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,219 @@
+package newznab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newznab+ wraps attributes in "@attributes" and uses a single object for a single attr
+const newznabPlusJSON = `{
+  "@attributes": {"version": "2.0"},
+  "channel": {
+    "title": "example",
+    "response": {"@attributes": {"offset": "0", "total": "1"}},
+    "item": {
+      "title": "Bones.S10E22.DVDRip.X264-REWARD",
+      "guid": "http://example.com/details/abc",
+      "link": "http://example.com/getnzb/abc.nzb",
+      "pubDate": "Thu, 04 May 2017 12:00:00 +0000",
+      "category": "TV > SD",
+      "enclosure": {"@attributes": {"url": "http://example.com/getnzb/abc.nzb", "length": "460000000", "type": "application/x-nzb"}},
+      "attr": [
+        {"@attributes": {"name": "category", "value": "5000"}},
+        {"@attributes": {"name": "category", "value": "5030"}},
+        {"@attributes": {"name": "size", "value": "460000000"}},
+        {"@attributes": {"name": "guid", "value": "abc"}},
+        {"@attributes": {"name": "tvdbid", "value": "75682"}}
+      ]
+    }
+  }
+}`
+
+// nZEDb uses "newznab:attr" keys and numbers for the response
+const nzedbJSON = `{
+  "@attributes": {"version": "2.0"},
+  "channel": {
+    "response": {"@attributes": {"offset": 100, "total": 250}},
+    "item": [{
+      "title": "Movie.2016.1080p.BluRay.x264-GRP",
+      "guid": {"@attributes": {"isPermaLink": "true"}, "#text": "http://example.com/details/def"},
+      "pubDate": "Thu, 04 May 2017 12:00:00 +0000",
+      "enclosure": {"@attributes": {"url": "http://example.com/getnzb/def.nzb", "length": 8000000000, "type": "application/x-nzb"}},
+      "newznab:attr": [
+        {"@attributes": {"name": "guid", "value": "def"}},
+        {"@attributes": {"name": "imdb", "value": "0364569"}},
+        {"@attributes": {"name": "grabs", "value": 12}}
+      ]
+    }]
+  }
+}`
+
+// NNTmux drops the "@attributes" wrappers and keeps the items and response outside the channel
+const nntmuxJSON = `{
+  "response": {"offset": 0, "total": 2},
+  "item": [
+    {"title": "Show.S01E02.720p.HDTV.x264-GRP", "pubDate": "2017-05-04T12:00:00Z", "size": 1536,
+     "attr": [{"name": "guid", "value": "ghi"}, {"name": "season", "value": "S01"}, {"name": "episode", "value": "E02"}]},
+    {"title": "Show.S01E03.720p.HDTV.x264-GRP", "attr": {"guid": "jkl", "grabs": "3"}}
+  ],
+  "channel": {"title": "nntmux"}
+}`
+
+func TestDecodeJSONFeed(t *testing.T) {
+	client := New("http://example.com", "gibberish", 1234, false)
+	pub := time.Date(2017, 5, 4, 12, 0, 0, 0, time.UTC)
+	toNZBs := func(t *testing.T, data string) ([]NZB, SearchResponse) {
+		feed, err := decodeJSONFeed([]byte(data))
+		require.NoError(t, err)
+		var nzbs []NZB
+		for _, raw := range feed.Channel.NZBs {
+			nzbs = append(nzbs, client.toNZB(raw))
+		}
+		return nzbs, feed
+	}
+
+	t.Run("newznab+", func(t *testing.T) {
+		nzbs, feed := toNZBs(t, newznabPlusJSON)
+		require.Equal(t, 1, feed.Channel.Response.Total)
+		require.Len(t, nzbs, 1)
+		require.Equal(t, "abc", nzbs[0].ID)
+		require.Equal(t, "Bones.S10E22.DVDRip.X264-REWARD", nzbs[0].Title)
+		require.Equal(t, []string{"5000", "5030"}, nzbs[0].Category)
+		require.Equal(t, int64(460000000), nzbs[0].Size)
+		require.Equal(t, "75682", nzbs[0].TVDBID)
+		require.Equal(t, "http://example.com/getnzb/abc.nzb", nzbs[0].DownloadURL)
+		require.True(t, pub.Equal(nzbs[0].PubDate))
+	})
+
+	t.Run("nzedb", func(t *testing.T) {
+		nzbs, feed := toNZBs(t, nzedbJSON)
+		require.Equal(t, 100, feed.Channel.Response.Offset)
+		require.Equal(t, 250, feed.Channel.Response.Total)
+		require.Equal(t, "http://example.com/details/def", feed.Channel.NZBs[0].GUID.GUID)
+		require.Equal(t, "def", nzbs[0].ID)
+		require.Equal(t, "0364569", nzbs[0].IMDBID)
+		require.Equal(t, 12, nzbs[0].NumGrabs)
+		require.Equal(t, "8000000000", feed.Channel.NZBs[0].Enclosure.Length)
+	})
+
+	t.Run("nntmux", func(t *testing.T) {
+		nzbs, feed := toNZBs(t, nntmuxJSON)
+		require.Equal(t, "nntmux", feed.Channel.Title)
+		require.Equal(t, 2, feed.Channel.Response.Total)
+		require.Len(t, nzbs, 2)
+		require.Equal(t, "ghi", nzbs[0].ID)
+		require.Equal(t, int64(1536), nzbs[0].Size)
+		require.Equal(t, "E02", nzbs[0].Episode)
+		require.True(t, pub.Equal(nzbs[0].PubDate))
+		require.Equal(t, "jkl", nzbs[1].ID)
+		require.Equal(t, 3, nzbs[1].NumGrabs)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		for _, data := range []string{
+			`{"error": {"@attributes": {"code": "100", "description": "Incorrect user credentials"}}}`,
+			`{"error": {"code": 100, "description": "Incorrect user credentials"}}`,
+		} {
+			feed, err := decodeJSONFeed([]byte(data))
+			require.NoError(t, err)
+			require.Equal(t, 100, feed.ErrorCode)
+			require.Equal(t, "Incorrect user credentials", feed.ErrorDesc)
+		}
+	})
+
+	t.Run("no results", func(t *testing.T) {
+		for _, data := range []string{
+			`{"@attributes": {"version": "2.0"}, "channel": {"title": "example", "response": {"@attributes": {"offset": "0", "total": "0"}}}}`,
+			`{"channel": {}}`,
+			`{"title": "example", "response": {"offset": 0, "total": 0}}`,
+		} {
+			feed, err := decodeJSONFeed([]byte(data))
+			require.NoError(t, err, data)
+			require.Empty(t, feed.Channel.NZBs, data)
+			require.Zero(t, feed.Channel.Response.Total, data)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, data := range []string{`<rss/>`, `{"title": "no items"}`, `{"channel": {"item": ["text"]}}`} {
+			_, err := decodeJSONFeed([]byte(data))
+			require.Error(t, err, data)
+		}
+	})
+}
+
+func TestResponseFormat(t *testing.T) {
+	const xmlFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/">
+<channel><item><title>from xml</title><newznab:attr name="guid" value="xml"/></item></channel>
+</rss>`
+	var formats []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("o")
+		formats = append(formats, format)
+		switch {
+		case format != "json" || r.URL.Query().Get("q") == "xml only":
+			w.Write([]byte(xmlFeed)) // nolint:errcheck
+		case r.URL.Query().Get("q") == "nothing":
+			w.Write([]byte(`{"channel": {"response": {"@attributes": {"offset": "0", "total": "0"}}}}`)) // nolint:errcheck
+		case r.URL.Query().Get("q") == "broken":
+			w.Write([]byte(`{"channel": `)) // nolint:errcheck
+		case r.URL.Query().Get("apikey") != "gibberish" && r.URL.Query().Get("r") != "gibberish":
+			w.Write([]byte(`{"error": {"@attributes": {"code": "100", "description": "Incorrect user credentials"}}}`)) // nolint:errcheck
+		default:
+			w.Write([]byte(newznabPlusJSON)) // nolint:errcheck
+		}
+	}))
+	defer ts.Close()
+	client := New(ts.URL, "gibberish", 1234, false).WithResponseFormat(FormatJSON)
+
+	t.Run("json", func(t *testing.T) {
+		formats = nil
+		page, err := client.Search(context.Background(), SearchRequest{Query: "bones"})
+		require.NoError(t, err)
+		require.Equal(t, 1, page.Total)
+		require.Equal(t, "abc", page.NZBs[0].ID)
+		require.Equal(t, []string{"json"}, formats)
+	})
+
+	t.Run("indexer answers with xml", func(t *testing.T) {
+		formats = nil
+		nzbs, err := client.SearchWithQuery(nil, "xml only", "search")
+		require.NoError(t, err)
+		require.Equal(t, "xml", nzbs[0].ID)
+		require.Equal(t, []string{"json"}, formats, "the xml answer is used without a second request")
+	})
+
+	t.Run("falls back to xml", func(t *testing.T) {
+		formats = nil
+		nzbs, err := client.SearchWithQuery(nil, "broken", "search")
+		require.NoError(t, err)
+		require.Equal(t, "xml", nzbs[0].ID)
+		require.Equal(t, []string{"json", ""}, formats)
+	})
+
+	t.Run("json without results", func(t *testing.T) {
+		formats = nil
+		nzbs, err := client.SearchWithQuery(nil, "nothing", "search")
+		require.NoError(t, err)
+		require.Empty(t, nzbs)
+		require.Equal(t, []string{"json"}, formats, "no results is not a reason to ask for xml")
+	})
+
+	t.Run("json error", func(t *testing.T) {
+		_, err := New(ts.URL, "wrong", 1234, false).WithResponseFormat(FormatJSON).SearchWithQuery(nil, "bones", "search")
+		require.EqualError(t, err, "newznab api error 100: Incorrect user credentials")
+	})
+
+	t.Run("rss", func(t *testing.T) {
+		formats = nil
+		_, err := client.LoadRSSFeed(nil, 10)
+		require.NoError(t, err)
+		require.Equal(t, []string{"json"}, formats)
+	})
+}
@@ -1,6 +1,8 @@
 package newznab
 
 import (
+	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/xml"
 	"io/ioutil"
@@ -8,7 +10,6 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -57,6 +58,15 @@ type Client struct {
 	apiBaseURL string
 	apiUserID  int
 	client     *http.Client
+	// customAPIPath and customRSSPath replace apiPath and rssPath for indexers serving them elsewhere
+	customAPIPath string
+	customRSSPath string
+	// format is the response format of searches, XML when empty
+	format ResponseFormat
+	// categories names the categories of results, the standard tree when nil
+	categories *CategoryTree
+	// defaultCategories are searched when a request doesn't ask for any
+	defaultCategories []int
 }
 
 // New returns a new instance of Client
@@ -77,55 +87,91 @@ func New(baseURL string, apikey string, userID int, insecure bool) Client {
 	return ret
 }
 
-// SearchWithTVRage returns NZBs for the given parameters
+// WithTransport returns a copy of this client that sends its HTTP requests through the given RoundTripper
+func (c Client) WithTransport(transport http.RoundTripper) Client {
+	httpClient := *c.client
+	httpClient.Transport = transport
+	c.client = &httpClient
+	return c
+}
+
+// Transport returns the RoundTripper this client sends its HTTP requests through
+func (c Client) Transport() http.RoundTripper {
+	if c.client.Transport == nil {
+		return http.DefaultTransport
+	}
+	return c.client.Transport
+}
+
+// WithAPIPath returns a copy of this client that sends api requests to the given path instead of /api.
+// The path is joined to the base URL, "." uses the base URL itself and an absolute URL replaces it.
+func (c Client) WithAPIPath(path string) Client {
+	c.customAPIPath = path
+	return c
+}
+
+// WithRSSPath returns a copy of this client that loads RSS feeds from the given path instead of /rss.
+// The path is joined to the base URL, an absolute URL replaces the base URL for RSS requests.
+func (c Client) WithRSSPath(path string) Client {
+	c.customRSSPath = path
+	return c
+}
+
+// SearchWithTVRage returns NZBs for the given parameters. An episode of 0 searches the whole season,
+// season 0 holds the specials.
 func (c Client) SearchWithTVRage(categories []int, tvRageID int, season int, episode int) ([]NZB, error) {
-	return c.search(url.Values{
-		"rid":     []string{strconv.Itoa(tvRageID)},
-		"cat":     c.splitCats(categories),
-		"season":  []string{strconv.Itoa(season)},
-		"episode": []string{strconv.Itoa(episode)},
-		"t":       []string{"tvsearch"},
-	})
+	return c.search(c.tvValues("rid", tvRageID, categories, season, episode))
 }
 
-// SearchWithTVDB returns NZBs for the given parameters
+// SearchWithTVDB returns NZBs for the given parameters. An episode of 0 searches the whole season,
+// season 0 holds the specials.
 func (c Client) SearchWithTVDB(categories []int, tvDBID int, season int, episode int) ([]NZB, error) {
-	return c.search(url.Values{
-		"tvdbid":  []string{strconv.Itoa(tvDBID)},
-		"cat":     c.splitCats(categories),
-		"season":  []string{strconv.Itoa(season)},
-		"episode": []string{strconv.Itoa(episode)},
-		"t":       []string{"tvsearch"},
-	})
+	return c.search(c.tvValues("tvdbid", tvDBID, categories, season, episode))
 }
 
-// SearchWithTVMaze returns NZBs for the given parameters
+// SearchWithTVMaze returns NZBs for the given parameters. An episode of 0 searches the whole season,
+// season 0 holds the specials.
 func (c Client) SearchWithTVMaze(categories []int, tvMazeID int, season int, episode int) ([]NZB, error) {
-	return c.search(url.Values{
-		"tvmazeid": []string{strconv.Itoa(tvMazeID)},
-		"cat":      c.splitCats(categories),
-		"season":   []string{strconv.Itoa(season)},
-		"episode":  []string{strconv.Itoa(episode)},
-		"t":        []string{"tvsearch"},
-	})
+	return c.search(c.tvValues("tvmazeid", tvMazeID, categories, season, episode))
 }
 
-// SearchWithIMDB returns NZBs for the given parameters
+// SearchWithIMDB returns NZBs for the given parameters, the imdb id is sent without the "tt" prefix
 func (c Client) SearchWithIMDB(categories []int, imdbID string) ([]NZB, error) {
-	return c.search(url.Values{
-		"imdbid": []string{imdbID},
-		"cat":    c.splitCats(categories),
-		"t":      []string{"movie"},
-	})
+	return c.search(c.imdbValues(categories, imdbID))
 }
 
 // SearchWithQuery returns NZBs for the given parameters
 func (c Client) SearchWithQuery(categories []int, query string, searchType string) ([]NZB, error) {
-	return c.search(url.Values{
+	return c.search(c.queryValues(categories, query, searchType))
+}
+
+func (c Client) tvValues(idParam string, id int, categories []int, season int, episode int) url.Values {
+	vals := url.Values{
+		idParam:  []string{strconv.Itoa(id)},
+		"cat":    c.splitCats(categories),
+		"season": []string{strconv.Itoa(season)},
+		"t":      []string{"tvsearch"},
+	}
+	if episode != 0 {
+		vals.Set("ep", strconv.Itoa(episode))
+	}
+	return vals
+}
+
+func (c Client) imdbValues(categories []int, imdbID string) url.Values {
+	return url.Values{
+		"imdbid": []string{imdbParam(imdbID)},
+		"cat":    c.splitCats(categories),
+		"t":      []string{"movie"},
+	}
+}
+
+func (c Client) queryValues(categories []int, query string, searchType string) url.Values {
+	return url.Values{
 		"q":   []string{query},
 		"cat": c.splitCats(categories),
 		"t":   []string{searchType},
-	})
+	}
 }
 
 // LoadRSSFeed returns up to <num> of the most recent NZBs of the given categories.
@@ -139,33 +185,30 @@ func (c Client) LoadRSSFeed(categories []int, num int) ([]NZB, error) {
 
 // Capabilities returns the capabilities of this tracker
 func (c Client) Capabilities() (Capabilities, error) {
-	return c.caps(url.Values{
+	return c.caps(context.Background(), url.Values{
 		"t": []string{"caps"},
 	})
 }
 
 // LoadRSSFeedUntilNZBID fetches NZBs until a given NZB id is reached.
+// It stops early when the feed runs out of items. Items without an id are matched by their download URL,
+// like the checkpoints of a Watcher.
 func (c Client) LoadRSSFeedUntilNZBID(categories []int, num int, id string, maxRequests int) ([]NZB, error) {
 	count := 0
 	var nzbs []NZB
 	for {
-		partition, err := c.rss(url.Values{
-			"num":    []string{strconv.Itoa(num)},
-			"t":      c.splitCats(categories),
-			"dl":     []string{"1"},
-			"offset": []string{strconv.Itoa(num * count)},
-		})
+		partition, err := c.loadRSSPage(context.Background(), categories, num, num*count)
 		count++
 		if err != nil {
 			return nil, err
 		}
 		for k, nzb := range partition {
-			if nzb.ID == id {
+			if id != "" && watchKey(nzb) == id {
 				return append(nzbs, partition[:k]...), nil
 			}
 		}
 		nzbs = append(nzbs, partition...)
-		if maxRequests != 0 && count == maxRequests {
+		if len(partition) == 0 || (maxRequests != 0 && count == maxRequests) {
 			break
 		}
 	}
@@ -180,7 +223,30 @@ func (c Client) Details(guid string) (Details, error) {
 	})
 }
 
+// DetailsNZB gets the details of a particular nzb mapped onto an NZB, including details-only
+// attributes like the password state, number of files, group and poster
+func (c Client) DetailsNZB(guid string) (NZB, error) {
+	nzbs, err := c.search(url.Values{
+		"t":    []string{"details"},
+		"guid": []string{guid},
+	})
+	if err != nil {
+		return NZB{}, errors.Wrap(err, "failed to get details")
+	}
+	if len(nzbs) == 0 {
+		return NZB{}, NewAPIError(ErrorNoSuchItem)
+	}
+	nzb := nzbs[0]
+	if nzb.ID == "" {
+		nzb.ID = guid
+	}
+	return nzb, nil
+}
+
 func (c Client) splitCats(cats []int) []string {
+	if len(cats) == 0 {
+		cats = c.defaultCategories
+	}
 	var categories, catsOut []string
 	for _, v := range cats {
 		categories = append(categories, strconv.Itoa(v))
@@ -189,20 +255,46 @@ func (c Client) splitCats(cats []int) []string {
 	return catsOut
 }
 
+func (c Client) loadRSSPage(ctx context.Context, categories []int, num int, offset int) ([]NZB, error) {
+	vals := url.Values{
+		"num":    []string{strconv.Itoa(num)},
+		"t":      c.splitCats(categories),
+		"dl":     []string{"1"},
+		"offset": []string{strconv.Itoa(offset)},
+	}
+	c.rssAuth(vals)
+	page, err := c.processPageContext(ctx, vals, rssPath)
+	return page.NZBs, err
+}
+
 func (c Client) rss(vals url.Values) ([]NZB, error) {
+	c.rssAuth(vals)
+	return c.process(vals, rssPath)
+}
+
+// rssAuth sets the credentials used by the rss endpoint, which takes the api key as r and the user id as i
+func (c Client) rssAuth(vals url.Values) {
 	vals.Set("r", c.apikey)
 	vals.Set("i", strconv.Itoa(c.apiUserID))
-	return c.process(vals, rssPath)
 }
 
 func (c Client) search(vals url.Values) ([]NZB, error) {
+	return c.searchContext(context.Background(), vals)
+}
+
+func (c Client) searchContext(ctx context.Context, vals url.Values) ([]NZB, error) {
 	vals.Set("apikey", c.apikey)
-	return c.process(vals, apiPath)
+	page, err := c.processPageContext(ctx, vals, apiPath)
+	return page.NZBs, err
 }
 
-func (c Client) caps(vals url.Values) (Capabilities, error) {
+func (c Client) caps(ctx context.Context, vals url.Values) (Capabilities, error) {
 	vals.Set("apikey", c.apikey)
-	resp, err := c.getURL(c.buildURL(vals, apiPath))
+	capsURL, err := c.buildURL(vals, apiPath)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	resp, err := c.getURLContext(ctx, capsURL)
 	if err != nil {
 		return Capabilities{}, errors.Wrap(err, "failed to get capabilities")
 	}
@@ -227,143 +319,191 @@ func (c Client) details(vals url.Values) (Details, error) {
 }
 
 func (c Client) process(vals url.Values, path string) ([]NZB, error) {
-	var nzbs []NZB
-	resp, err := c.getURL(c.buildURL(vals, path))
-	if err != nil {
-		return nzbs, err
+	page, err := c.processPage(vals, path)
+	return page.NZBs, err
+}
+
+func (c Client) processPage(vals url.Values, path string) (SearchPage, error) {
+	return c.processPageContext(context.Background(), vals, path)
+}
+
+func (c Client) processPageContext(ctx context.Context, vals url.Values, path string) (SearchPage, error) {
+	if c.format == FormatJSON {
+		page, ok, err := c.processJSONPage(ctx, vals, path)
+		if ok || err != nil {
+			return page, err
+		}
 	}
-	var feed SearchResponse
-	err = xml.Unmarshal(resp, &feed)
+	pageURL, err := c.buildURL(vals, path)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to unmarshal xml feed")
+		return SearchPage{}, err
 	}
-	if feed.ErrorCode != 0 {
-		return nil, errors.Errorf("newznab api error %d: %s", feed.ErrorCode, feed.ErrorDesc)
-	}
-	for _, gotNZB := range feed.Channel.NZBs {
-		nzb := NZB{
-			Title:          gotNZB.Title,
-			Description:    gotNZB.Description,
-			PubDate:        gotNZB.Date.Add(0),
-			DownloadURL:    gotNZB.Enclosure.URL,
-			SourceEndpoint: c.apiBaseURL,
-			SourceAPIKey:   c.apikey,
-		}
-		for _, attr := range gotNZB.Attributes {
-			switch attr.Name {
-			case "tvairdate":
-				if parsedAirDate, err := parseDate(attr.Value); err != nil {
-					log.WithError(err).WithField("tvairdate", attr.Value).Debug("newznab:Client:Search: failed to parse tvairdate")
-				} else {
-					nzb.AirDate = parsedAirDate
-				}
-			case "guid":
-				nzb.ID = attr.Value
-			case "size":
-				parsedInt, _ := strconv.ParseInt(attr.Value, 0, 64)
-				nzb.Size = parsedInt
-			case "grabs":
-				parsedInt, _ := strconv.ParseInt(attr.Value, 0, 32)
-				nzb.NumGrabs = int(parsedInt)
-			case "comments":
-				parsedInt, _ := strconv.ParseInt(attr.Value, 0, 32)
-				nzb.NumComments = int(parsedInt)
-			case "seeders":
-				parsedInt, _ := strconv.ParseInt(attr.Value, 0, 32)
-				nzb.Seeders = int(parsedInt)
-				nzb.IsTorrent = true
-			case "peers":
-				parsedInt, _ := strconv.ParseInt(attr.Value, 0, 32)
-				nzb.Peers = int(parsedInt)
-				nzb.IsTorrent = true
-			case "infohash":
-				nzb.InfoHash = attr.Value
-				nzb.IsTorrent = true
-			case "category":
-				nzb.Category = append(nzb.Category, attr.Value)
-			case "genre":
-				nzb.Genre = attr.Value
-			case "tvdbid":
-				nzb.TVDBID = attr.Value
-			case "rageid":
-				nzb.TVRageID = attr.Value
-			case "tvmazeid":
-				nzb.TVMazeID = attr.Value
-			case "info":
-				nzb.Info = attr.Value
-			case "season":
-				nzb.Season = attr.Value
-			case "episode":
-				nzb.Episode = attr.Value
-			case "tvtitle":
-				nzb.TVTitle = attr.Value
-			case "rating":
-				parsedInt, _ := strconv.ParseInt(attr.Value, 0, 32)
-				nzb.Rating = int(parsedInt)
-			case "imdb":
-				nzb.IMDBID = attr.Value
-			case "imdbtitle":
-				nzb.IMDBTitle = attr.Value
-			case "imdbyear":
-				parsedInt, _ := strconv.ParseInt(attr.Value, 0, 32)
-				nzb.IMDBYear = int(parsedInt)
-			case "imdbscore":
-				parsedFloat, _ := strconv.ParseFloat(attr.Value, 32)
-				nzb.IMDBScore = float32(parsedFloat)
-			case "coverurl":
-				nzb.CoverURL = attr.Value
-			case "usenetdate":
-				if parsedUsetnetDate, err := parseDate(attr.Value); err != nil {
-					log.WithError(err).WithField("usenetdate", attr.Value).Debug("failed to parse usenetdate")
-				} else {
-					nzb.UsenetDate = parsedUsetnetDate
-				}
-			case "resolution":
-				nzb.Resolution = attr.Value
-			default:
-				log.WithFields(log.Fields{
-					"name":  attr.Name,
-					"value": attr.Value,
-				}).Debug("encontered unknown attribute")
-			}
-		}
-		if nzb.Size == 0 {
-			nzb.Size = gotNZB.Size
-		}
-		nzbs = append(nzbs, nzb)
+	body, err := c.openURL(ctx, pageURL)
+	if err != nil {
+		return SearchPage{}, err
 	}
-	return nzbs, nil
+	defer body.Close()
+	return c.collectFeed(body)
 }
 
-// PopulateComments fills in the Comments for the given NZB
-func (c Client) PopulateComments(nzb *NZB) error {
-	data, err := c.getURL(c.buildURL(url.Values{
-		"t":      []string{"comments"},
-		"id":     []string{nzb.ID},
-		"apikey": []string{c.apikey},
-	}, apiPath))
+// processJSONPage loads a search or rss feed with o=json.
+// It reports false when the response can't be parsed and the feed should be requested as XML.
+func (c Client) processJSONPage(ctx context.Context, vals url.Values, path string) (SearchPage, bool, error) {
+	jsonVals := url.Values{}
+	for key, values := range vals {
+		jsonVals[key] = values
+	}
+	jsonVals.Set("o", string(FormatJSON))
+	jsonURL, err := c.buildURL(jsonVals, path)
 	if err != nil {
-		return err
+		return SearchPage{}, false, err
 	}
-	var resp commentResponse
-	err = xml.Unmarshal(data, &resp)
+	resp, err := c.getURLContext(ctx, jsonURL)
 	if err != nil {
-		return errors.Wrap(err, "failed to unmarshal comments xml data")
+		return SearchPage{}, false, err
 	}
-
-	for _, rawComment := range resp.Channel.Comments {
-		comment := Comment{
-			Title:   rawComment.Title,
-			Content: rawComment.Description,
+	feed, jsonErr := decodeJSONFeed(resp)
+	if jsonErr != nil {
+		// Indexers without JSON support ignore o=json
+		page, err := c.collectFeed(bytes.NewReader(resp))
+		if _, isAPIErr := err.(*APIError); err == nil || isAPIErr {
+			return page, true, err
 		}
-		if parsedPubDate, err := time.Parse(time.RFC1123Z, rawComment.PubDate); err != nil {
-			log.WithError(err).WithField("pubdate", rawComment.PubDate).Debug("failed to parse comment date")
-		} else {
-			comment.PubDate = parsedPubDate
+		log.WithError(jsonErr).Debug("failed to parse json feed, requesting xml")
+		return SearchPage{}, false, nil
+	}
+	if feed.ErrorCode != 0 {
+		return SearchPage{}, true, &APIError{Code: feed.ErrorCode, Description: feed.ErrorDesc}
+	}
+	page := SearchPage{
+		Offset: feed.Channel.Response.Offset,
+		Total:  feed.Channel.Response.Total,
+	}
+	for i, gotNZB := range feed.Channel.NZBs {
+		nzb, warnings := c.parseNZB(gotNZB)
+		page.NZBs = append(page.NZBs, nzb)
+		page.addWarnings(i, warnings)
+	}
+	return page, true, nil
+}
+
+// toNZB maps a feed item and its attributes onto an NZB
+func (c Client) toNZB(gotNZB RawNZB) NZB {
+	nzb, _ := c.parseNZB(gotNZB)
+	return nzb
+}
+
+// parseNZB maps a feed item onto an NZB and returns the values that couldn't be parsed, without the item index
+func (c Client) parseNZB(gotNZB RawNZB) (NZB, []ParseWarning) {
+	parser := attrParser{warnings: append([]ParseWarning(nil), gotNZB.warnings...)}
+	nzb := NZB{
+		Title:          gotNZB.Title,
+		Description:    gotNZB.Description,
+		PubDate:        gotNZB.Date.Add(0),
+		DownloadURL:    gotNZB.Enclosure.URL,
+		SourceEndpoint: c.apiBaseURL,
+		SourceAPIKey:   c.apikey,
+	}
+	for _, attr := range gotNZB.Attributes {
+		switch attr.Name {
+		case "tvairdate":
+			nzb.AirDate = parser.date(attr)
+		case "guid":
+			nzb.ID = attr.Value
+		case "size":
+			nzb.Size = parser.int(attr, 64)
+		case "grabs":
+			nzb.NumGrabs = int(parser.int(attr, 32))
+		case "comments":
+			nzb.NumComments = int(parser.int(attr, 32))
+		case "seeders":
+			nzb.Seeders = int(parser.int(attr, 32))
+			nzb.IsTorrent = true
+		case "peers":
+			nzb.Peers = int(parser.int(attr, 32))
+			nzb.IsTorrent = true
+		case "infohash":
+			nzb.InfoHash = attr.Value
+			nzb.IsTorrent = true
+		case "downloadvolumefactor":
+			if parsedFloat, ok := parser.float(attr, 64); ok {
+				nzb.DownloadVolumeFactor = &parsedFloat
+			}
+			nzb.IsTorrent = true
+		case "uploadvolumefactor":
+			if parsedFloat, ok := parser.float(attr, 64); ok {
+				nzb.UploadVolumeFactor = &parsedFloat
+			}
+			nzb.IsTorrent = true
+		case "category":
+			nzb.Category = append(nzb.Category, attr.Value)
+		case "genre":
+			nzb.Genre = attr.Value
+		case "tvdbid":
+			nzb.TVDBID = attr.Value
+		case "rageid":
+			nzb.TVRageID = attr.Value
+		case "tvmazeid":
+			nzb.TVMazeID = attr.Value
+		case "info":
+			nzb.Info = attr.Value
+		case "season":
+			nzb.Season = attr.Value
+		case "episode":
+			nzb.Episode = attr.Value
+		case "tvtitle":
+			nzb.TVTitle = attr.Value
+		case "rating":
+			nzb.Rating = int(parser.int(attr, 32))
+		case "imdb":
+			nzb.IMDBID = attr.Value
+		case "imdbid":
+			if nzb.IMDBID == "" {
+				nzb.IMDBID = attr.Value
+			}
+		case "tmdbid":
+			nzb.MediaIDs.TMDB = int(parser.int(attr, 32))
+		case "traktid":
+			nzb.MediaIDs.Trakt = int(parser.int(attr, 32))
+		case "doubanid":
+			nzb.MediaIDs.Douban = int(parser.int(attr, 32))
+		case "imdbtitle":
+			nzb.IMDBTitle = attr.Value
+		case "imdbyear":
+			nzb.IMDBYear = int(parser.int(attr, 32))
+		case "imdbscore":
+			parsedFloat, _ := parser.float(attr, 32)
+			nzb.IMDBScore = float32(parsedFloat)
+		case "coverurl":
+			nzb.CoverURL = attr.Value
+		case "usenetdate":
+			nzb.UsenetDate = parser.date(attr)
+		case "resolution":
+			nzb.Resolution = attr.Value
+		case "password":
+			nzb.Password = int(parser.int(attr, 32))
+		case "files":
+			nzb.NumFiles = int(parser.int(attr, 32))
+		case "group":
+			nzb.Group = attr.Value
+		case "poster":
+			nzb.Poster = attr.Value
+		case "nfo":
+			nzb.HasNFO = attr.Value == "1"
+		default:
+			log.WithFields(log.Fields{
+				"name":  attr.Name,
+				"value": attr.Value,
+			}).Debug("encontered unknown attribute")
 		}
-		nzb.Comments = append(nzb.Comments, comment)
 	}
-	return nil
+	if nzb.Size == 0 {
+		nzb.Size = gotNZB.Size
+	}
+	parser.parseEpisode(&nzb)
+	parser.parseIDs(&nzb)
+	parser.parseCategories(&nzb, c.categoryTree())
+	return nzb, parser.warnings
 }
 
 // NZBDownloadURL returns a URL to download the NZB from
@@ -384,40 +524,92 @@ func (c Client) getURL(url string, err error) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	res, err := c.client.Get(url)
+	return c.getURLContext(context.Background(), url)
+}
+
+func (c Client) getURLContext(ctx context.Context, url string) ([]byte, error) {
+	body, err := c.openURL(ctx, url)
 	if err != nil {
-		return nil, errors.Wrapf(err, "http request failed: %s", url)
+		return nil, err
 	}
 
 	var data []byte
-	data, err = ioutil.ReadAll(res.Body)
-	res.Body.Close()
+	data, err = ioutil.ReadAll(body)
+	body.Close()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to read response body")
 	}
 	return data, nil
 }
 
+// action calls an api function like commentadd or cartadd and returns the id in its response
+func (c Client) action(ctx context.Context, vals url.Values) (string, error) {
+	vals.Set("apikey", c.apikey)
+	actionURL, err := c.buildURL(vals, apiPath)
+	if err != nil {
+		return "", err
+	}
+	data, err := c.getURLContext(ctx, actionURL)
+	if err != nil {
+		return "", err
+	}
+	var resp actionResponse
+	err = xml.Unmarshal(data, &resp)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to unmarshal %s xml data", vals.Get("t"))
+	}
+	if resp.XMLName.Local == "error" {
+		return "", &APIError{Code: resp.Code, Description: resp.Description}
+	}
+	return resp.ID, nil
+}
+
+// buildURL joins the endpoint for path onto the base URL.
+// Query parameters already present on the base URL or endpoint are kept unless vals overrides them.
 func (c Client) buildURL(vals url.Values, path string) (string, error) {
-	parsedURL, err := url.Parse(c.apiBaseURL + path)
+	switch {
+	case path == apiPath && c.customAPIPath != "":
+		path = c.customAPIPath
+	case path == rssPath && c.customRSSPath != "":
+		path = c.customRSSPath
+	}
+	baseURL, err := url.Parse(c.apiBaseURL)
 	if err != nil {
 		return "", errors.Wrap(err, "failed to parse base API url")
 	}
+	endpoint, err := url.Parse(path)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse API path")
+	}
 
-	parsedURL.RawQuery = vals.Encode()
+	var parsedURL url.URL
+	query := url.Values{}
+	if endpoint.IsAbs() {
+		parsedURL = *endpoint
+	} else {
+		parsedURL = *baseURL
+		parsedURL.Path = joinPath(baseURL.Path, endpoint.Path)
+		parsedURL.RawPath = ""
+		mergeValues(query, baseURL.Query())
+	}
+	mergeValues(query, endpoint.Query())
+	mergeValues(query, vals)
+	parsedURL.RawQuery = query.Encode()
+	parsedURL.Fragment = ""
 	return parsedURL.String(), nil
 }
 
-func parseDate(date string) (time.Time, error) {
-	formats := []string{time.RFC3339, time.RFC1123Z}
-	var parsedTime time.Time
-	var err error
-	for _, format := range formats {
-		if parsedTime, err = time.Parse(format, date); err == nil {
-			return parsedTime, nil
-		}
+func joinPath(base string, path string) string {
+	if path == "" || path == "." {
+		return base
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+func mergeValues(dst url.Values, src url.Values) {
+	for key, values := range src {
+		dst[key] = values
 	}
-	return parsedTime, errors.Errorf("failed to parse date %s as one of %s", date, strings.Join(formats, ", "))
 }
 
 const (
@@ -425,14 +617,10 @@ const (
 	rssPath = "/rss"
 )
 
-type commentResponse struct {
-	Channel struct {
-		Comments []rssComment `xml:"item"`
-	} `xml:"channel"`
-}
-
-type rssComment struct {
-	Title       string `xml:"title"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+// actionResponse is the reply to api functions like commentadd: <commentadd id="..."/> or an <error>
+type actionResponse struct {
+	XMLName     xml.Name
+	ID          string `xml:"id,attr"`
+	Code        int    `xml:"code,attr"`
+	Description string `xml:"description,attr"`
 }
@@ -125,6 +125,12 @@ func TestUsenetCrawlerClient(t *testing.T) {
 			require.Equal(t, "2050", results[22].Category[1])
 		})
 
+		t.Run("IMDB id with tt prefix", func(t *testing.T) {
+			results, err := client.SearchWithIMDB([]int{CategoryMovieHD}, "tt0364569")
+			require.NoError(t, err)
+			require.NotEmpty(t, results, "expected results")
+		})
+
 		t.Run("single category and IMDB id", func(t *testing.T) {
 			cats := []int{CategoryMovieHD}
 			results, err := client.SearchWithIMDB(cats, "0364569")
@@ -133,6 +139,7 @@ func TestUsenetCrawlerClient(t *testing.T) {
 
 			t.Run("movie specific fields", func(t *testing.T) {
 				require.Equal(t, "0364569", results[0].IMDBID)
+				require.Equal(t, "tt0364569", results[0].MediaIDs.IMDB)
 				require.Equal(t, "Oldboy", results[0].IMDBTitle)
 				require.Equal(t, 2003, results[0].IMDBYear)
 				require.Equal(t, float32(8.4), results[0].IMDBScore)
@@ -183,5 +190,21 @@ func TestUsenetCrawlerClient(t *testing.T) {
 			require.NoError(t, err)
 			require.Equal(t, "Car.Craft-July.2015", d.Channel.Item.Title)
 		})
+
+		t.Run("single nzb details mapped onto an nzb", func(t *testing.T) {
+			nzb, err := client.DetailsNZB("4694b91a86adc4ebd3b289687ebf4b0d")
+			require.NoError(t, err)
+			require.Equal(t, "4694b91a86adc4ebd3b289687ebf4b0d", nzb.ID)
+			require.Equal(t, "Car.Craft-July.2015", nzb.Title)
+			require.Equal(t, []string{"7000", "7010"}, nzb.Category)
+			require.Equal(t, int64(30383000), nzb.Size)
+			require.Equal(t, 9, nzb.NumGrabs)
+			require.Equal(t, 6, nzb.NumFiles)
+			require.Equal(t, 0, nzb.Password)
+			require.Equal(t, "anonymous", nzb.Poster)
+			require.Equal(t, "alt.binaries.nzb", nzb.Group)
+			require.Equal(t, 2015, nzb.PubDate.Year())
+			require.False(t, nzb.UsenetDate.IsZero())
+		})
 	})
 }
@@ -0,0 +1,217 @@
+package newznab
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// NFO is the info file of a release along with the metadata found in it
+type NFO struct {
+	Text string
+	// IMDBID is stored without the "tt" prefix, like the imdb attribute
+	IMDBID     string
+	TVDBID     string
+	TVMazeID   string
+	Runtime    time.Duration
+	Resolution string
+	// Video and Audio hold the specs as written in the NFO, e.g. "x264 @ 4500 Kbps"
+	Video string
+	Audio string
+	// PasswordHint is set when the NFO mentions a password for the archives
+	PasswordHint string
+}
+
+// GetNFO fetches the NFO of the given NZB with t=getnfo and parses it.
+// Indexers report whether an NFO exists with the nfo attribute, see NZB.HasNFO.
+func (c Client) GetNFO(ctx context.Context, nzb NZB) (NFO, error) {
+	nfoURL, err := c.buildURL(url.Values{
+		"t":      []string{"getnfo"},
+		"id":     []string{nzb.ID},
+		"raw":    []string{"1"},
+		"apikey": []string{c.apikey},
+	}, apiPath)
+	if err != nil {
+		return NFO{}, err
+	}
+	data, err := c.getURLContext(ctx, nfoURL)
+	if err != nil {
+		return NFO{}, err
+	}
+	text, err := nfoText(data)
+	if err != nil {
+		return NFO{}, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return NFO{}, NewAPIError(ErrorNoSuchItem)
+	}
+	return ParseNFO(text), nil
+}
+
+// nfoText returns the NFO in a getnfo response. Errors are returned as *APIError and indexers
+// that ignore raw=1 wrap the NFO in the description of an RSS item.
+func nfoText(data []byte) (string, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<rss")) || bytes.HasPrefix(trimmed, []byte("<error")) {
+		var feed SearchResponse
+		if err := xml.Unmarshal(trimmed, &feed); err != nil {
+			return "", errors.Wrap(err, "failed to unmarshal nfo xml")
+		}
+		if feed.ErrorCode != 0 {
+			return "", &APIError{Code: feed.ErrorCode, Description: feed.ErrorDesc}
+		}
+		if len(feed.Channel.NZBs) == 0 {
+			return "", nil
+		}
+		return feed.Channel.NZBs[0].Description, nil
+	}
+	return DecodeCP437(data), nil
+}
+
+// DecodeCP437 decodes NFO bytes, which are traditionally code page 437. Valid UTF-8 is returned as is.
+func DecodeCP437(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	var b strings.Builder
+	b.Grow(len(data))
+	for _, c := range data {
+		if c < 0x80 {
+			b.WriteByte(c)
+		} else {
+			b.WriteRune(cp437[c-0x80])
+		}
+	}
+	return b.String()
+}
+
+// cp437 maps the upper half of code page 437 to unicode
+var cp437 = [128]rune{
+	'Ç', 'ü', 'é', 'â', 'ä', 'à', 'å', 'ç', 'ê', 'ë', 'è', 'ï', 'î', 'ì', 'Ä', 'Å',
+	'É', 'æ', 'Æ', 'ô', 'ö', 'ò', 'û', 'ù', 'ÿ', 'Ö', 'Ü', '¢', '£', '¥', '₧', 'ƒ',
+	'á', 'í', 'ó', 'ú', 'ñ', 'Ñ', 'ª', 'º', '¿', '⌐', '¬', '½', '¼', '¡', '«', '»',
+	'░', '▒', '▓', '│', '┤', '╡', '╢', '╖', '╕', '╣', '║', '╗', '╝', '╜', '╛', '┐',
+	'└', '┴', '┬', '├', '─', '┼', '╞', '╟', '╚', '╔', '╩', '╦', '╠', '═', '╬', '╧',
+	'╨', '╤', '╥', '╙', '╘', '╒', '╓', '╫', '╪', '┘', '┌', '█', '▄', '▌', '▐', '▀',
+	'α', 'ß', 'Γ', 'π', 'Σ', 'σ', 'µ', 'τ', 'Φ', 'Θ', 'Ω', 'δ', '∞', 'φ', 'ε', '∩',
+	'≡', '±', '≥', '≤', '⌠', '⌡', '÷', '≈', '°', '∙', '·', '√', 'ⁿ', '²', '■', ' ',
+}
+
+var (
+	nfoIMDBRe       = regexp.MustCompile(`(?i)imdb\.[a-z.]+/title/tt(\d{7,8})`)
+	nfoTVDBRe       = regexp.MustCompile(`(?i)thetvdb\.com/\S*?(?:[?&;]id=|series/|/)(\d+)\b`)
+	nfoTVMazeRe     = regexp.MustCompile(`(?i)tvmaze\.com/shows/(\d+)`)
+	nfoFieldRe      = regexp.MustCompile(`(?im)^[^a-z0-9\n]*(video|audio|runtime|duration|length|playtime|resolution|password|pass|pw)\b[^a-z0-9\n]*(.*?)[^a-z0-9)\]\n]*$`)
+	nfoResolutionRe = regexp.MustCompile(`(\d{3,4})\s*[x×]\s*(\d{3,4})`)
+	nfoClockRe      = regexp.MustCompile(`\b(\d{1,2}):(\d{2})(?::(\d{2}))?\b`)
+	nfoUnitRe       = regexp.MustCompile(`(?i)(\d+)\s*(h|hr|hrs|hours?|mn|m|min|mins|minutes?|s|sec|secs|seconds?)\b`)
+)
+
+// ParseNFO extracts metadata from the text of an NFO
+func ParseNFO(text string) NFO {
+	nfo := NFO{Text: text}
+	if m := nfoIMDBRe.FindStringSubmatch(text); m != nil {
+		nfo.IMDBID = m[1]
+	}
+	if m := nfoTVDBRe.FindStringSubmatch(text); m != nil {
+		nfo.TVDBID = m[1]
+	}
+	if m := nfoTVMazeRe.FindStringSubmatch(text); m != nil {
+		nfo.TVMazeID = m[1]
+	}
+
+	for _, m := range nfoFieldRe.FindAllStringSubmatch(text, -1) {
+		value := strings.TrimSpace(m[2])
+		if value == "" {
+			continue
+		}
+		switch strings.ToLower(m[1]) {
+		case "video":
+			if nfo.Video == "" {
+				nfo.Video = value
+			}
+		case "audio":
+			if nfo.Audio == "" {
+				nfo.Audio = value
+			}
+		case "runtime", "duration", "length", "playtime":
+			if nfo.Runtime == 0 {
+				nfo.Runtime = parseRuntime(value)
+			}
+		case "resolution":
+			if res := nfoResolutionRe.FindStringSubmatch(value); res != nil {
+				nfo.Resolution = res[1] + "x" + res[2]
+			}
+		case "password", "pass", "pw":
+			if nfo.PasswordHint == "" && !isNoPassword(value) {
+				nfo.PasswordHint = value
+			}
+		}
+	}
+	if nfo.Resolution == "" {
+		if res := nfoResolutionRe.FindStringSubmatch(nfo.Video); res != nil {
+			nfo.Resolution = res[1] + "x" + res[2]
+		}
+	}
+	return nfo
+}
+
+// Fill sets metadata found in the NFO on the given NZB where it is missing, ids are set on both
+// the id fields and the MediaIDs
+func (n NFO) Fill(nzb *NZB) {
+	if nzb.IMDBID == "" {
+		nzb.IMDBID = n.IMDBID
+	}
+	if nzb.TVDBID == "" {
+		nzb.TVDBID = n.TVDBID
+	}
+	if nzb.TVMazeID == "" {
+		nzb.TVMazeID = n.TVMazeID
+	}
+	if nzb.Resolution == "" {
+		nzb.Resolution = n.Resolution
+	}
+	nzb.SyncMediaIDs()
+}
+
+// parseRuntime reads durations like "1h 52mn", "1:52:33" or "112 min"
+func parseRuntime(value string) time.Duration {
+	if m := nfoClockRe.FindStringSubmatch(value); m != nil {
+		a, _ := strconv.Atoi(m[1])
+		b, _ := strconv.Atoi(m[2])
+		if m[3] != "" {
+			c, _ := strconv.Atoi(m[3])
+			return time.Duration(a)*time.Hour + time.Duration(b)*time.Minute + time.Duration(c)*time.Second
+		}
+		return time.Duration(a)*time.Minute + time.Duration(b)*time.Second
+	}
+	var runtime time.Duration
+	for _, m := range nfoUnitRe.FindAllStringSubmatch(value, -1) {
+		amount, _ := strconv.Atoi(m[1])
+		switch unit := strings.ToLower(m[2]); {
+		case strings.HasPrefix(unit, "h"):
+			runtime += time.Duration(amount) * time.Hour
+		case strings.HasPrefix(unit, "s"):
+			runtime += time.Duration(amount) * time.Second
+		default:
+			runtime += time.Duration(amount) * time.Minute
+		}
+	}
+	return runtime
+}
+
+func isNoPassword(value string) bool {
+	switch strings.ToLower(strings.Trim(value, " .-!")) {
+	case "none", "no", "n/a", "na", "-", "":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package newznab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const sampleNFO = `
+        ███▄    █ ▒█████
+        RELEASE GROUP PRESENTS
+
+   Movie Name (2016)
+
+   IMDB.......: http://www.imdb.com/title/tt0364569/
+   Runtime....: 1h 52mn
+   Video......: x264 @ 4 500 Kbps, 1920x800
+   Audio......: English DTS 5.1 @ 1 509 Kbps
+   Password...: none
+
+   Greets to everyone
+`
+
+func TestParseNFO(t *testing.T) {
+	t.Run("movie", func(t *testing.T) {
+		nfo := ParseNFO(sampleNFO)
+		require.Equal(t, "0364569", nfo.IMDBID)
+		require.Equal(t, time.Hour+52*time.Minute, nfo.Runtime)
+		require.Equal(t, "x264 @ 4 500 Kbps, 1920x800", nfo.Video)
+		require.Equal(t, "English DTS 5.1 @ 1 509 Kbps", nfo.Audio)
+		require.Equal(t, "1920x800", nfo.Resolution)
+		require.Empty(t, nfo.PasswordHint)
+	})
+
+	t.Run("tv", func(t *testing.T) {
+		nfo := ParseNFO("TVDB: https://thetvdb.com/?tab=series&id=78901\nTVMaze: http://www.tvmaze.com/shows/65/bones\nDuration: 00:42:10\nResolution: 1280 x 720\nPW: www.example.com")
+		require.Equal(t, "78901", nfo.TVDBID)
+		require.Equal(t, "65", nfo.TVMazeID)
+		require.Equal(t, 42*time.Minute+10*time.Second, nfo.Runtime)
+		require.Equal(t, "1280x720", nfo.Resolution)
+		require.Equal(t, "www.example.com", nfo.PasswordHint)
+	})
+
+	t.Run("runtime formats", func(t *testing.T) {
+		require.Equal(t, 112*time.Minute, parseRuntime("112 min"))
+		require.Equal(t, 2*time.Hour+5*time.Minute, parseRuntime("2 hours 5 minutes"))
+		require.Equal(t, 45*time.Minute+30*time.Second, parseRuntime("45:30"))
+		require.Equal(t, time.Duration(0), parseRuntime("unknown"))
+	})
+
+	t.Run("fill", func(t *testing.T) {
+		nzb := NZB{TVDBID: "1234"}
+		ParseNFO(sampleNFO + "\nhttps://thetvdb.com/series/5678").Fill(&nzb)
+		require.Equal(t, "0364569", nzb.IMDBID)
+		require.Equal(t, "1234", nzb.TVDBID, "existing values are kept")
+		require.Equal(t, "1920x800", nzb.Resolution)
+		require.Equal(t, "tt0364569", nzb.MediaIDs.IMDB)
+		require.Equal(t, 1234, nzb.MediaIDs.TVDB)
+	})
+}
+
+func TestDecodeCP437(t *testing.T) {
+	require.Equal(t, "██ ░▒▓ é", DecodeCP437([]byte{0xDB, 0xDB, ' ', 0xB0, 0xB1, 0xB2, ' ', 0x82}))
+	require.Equal(t, "already utf-8 ██", DecodeCP437([]byte("already utf-8 ██")))
+}
+
+func TestGetNFO(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "getnfo", r.URL.Query().Get("t"))
+		switch r.URL.Query().Get("id") {
+		case "raw":
+			w.Write([]byte("\xDB\xDB Video: x264 1280x720\r\nhttp://www.imdb.com/title/tt1234567/\r\n")) // nolint:errcheck
+		case "rss":
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel><item><title>nfo</title><description>Runtime: 45 min</description></item></channel></rss>`)) // nolint:errcheck
+		default:
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><error code="300" description="No such item"/>`)) // nolint:errcheck
+		}
+	}))
+	defer ts.Close()
+	client := New(ts.URL, "gibberish", 1234, false)
+
+	t.Run("raw cp437", func(t *testing.T) {
+		nfo, err := client.GetNFO(context.Background(), NZB{ID: "raw"})
+		require.NoError(t, err)
+		require.Contains(t, nfo.Text, "██ Video")
+		require.Equal(t, "1234567", nfo.IMDBID)
+		require.Equal(t, "1280x720", nfo.Resolution)
+	})
+
+	t.Run("wrapped in rss", func(t *testing.T) {
+		nfo, err := client.GetNFO(context.Background(), NZB{ID: "rss"})
+		require.NoError(t, err)
+		require.Equal(t, 45*time.Minute, nfo.Runtime)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := client.GetNFO(context.Background(), NZB{ID: "missing"})
+		apiErr, ok := err.(*APIError)
+		require.True(t, ok)
+		require.Equal(t, ErrorNoSuchItem, apiErr.Code)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := client.GetNFO(ctx, NZB{ID: "raw"})
+		require.Error(t, err)
+	})
+}
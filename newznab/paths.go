@@ -0,0 +1,69 @@
+package newznab
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/url"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// CommonAPIPaths are the api locations tried by DetectAPIPath, in order.
+// The path "." means the base URL itself is the api endpoint.
+var CommonAPIPaths = []string{
+	"/api",
+	"/api/v1/api",
+	"/newznab/api",
+	"/torznab/api",
+	"/api/torznab",
+	".",
+}
+
+// ErrAPINotFound is returned by DetectAPIPath when none of the candidate paths answers like a newznab api
+var ErrAPINotFound = errors.New("no newznab api found")
+
+// DetectAPIPath probes the given paths, or CommonAPIPaths when none are given, with a caps request.
+// It returns a copy of this client using the first path that answers with newznab capabilities or a newznab error.
+func (c Client) DetectAPIPath(paths ...string) (Client, string, error) {
+	if len(paths) == 0 {
+		paths = CommonAPIPaths
+	}
+	for _, path := range paths {
+		probe := c.WithAPIPath(path)
+		ok, err := probe.probeAPI()
+		if err != nil {
+			log.WithError(err).WithField("path", path).Debug("api path probe failed")
+			continue
+		}
+		if ok {
+			return probe, path, nil
+		}
+	}
+	return c, "", ErrAPINotFound
+}
+
+// probeAPI reports whether the api endpoint answers a caps request with a <caps> or <error> document
+func (c Client) probeAPI() (bool, error) {
+	data, err := c.getURL(c.buildURL(url.Values{
+		"t":      []string{"caps"},
+		"apikey": []string{c.apikey},
+	}, apiPath))
+	if err != nil {
+		return false, err
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, errors.Wrap(err, "failed to parse response")
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local == "caps" || start.Name.Local == "error", nil
+		}
+	}
+}
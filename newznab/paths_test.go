@@ -0,0 +1,72 @@
+package newznab
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildURL(t *testing.T) {
+	vals := url.Values{"t": []string{"caps"}}
+	for _, tc := range []struct {
+		name    string
+		client  Client
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"default", New("https://indexer.net", "key", 1, false), apiPath, "https://indexer.net/api?t=caps", false},
+		{"trailing slash", New("https://indexer.net/", "key", 1, false), apiPath, "https://indexer.net/api?t=caps", false},
+		{"base path", New("https://indexer.net/prefix/", "key", 1, false), rssPath, "https://indexer.net/prefix/rss?t=caps", false},
+		{"base query", New("https://indexer.net/?passkey=abc", "key", 1, false), apiPath, "https://indexer.net/api?passkey=abc&t=caps", false},
+		{"api override", New("https://indexer.net", "key", 1, false).WithAPIPath("/api/v1/api"), apiPath, "https://indexer.net/api/v1/api?t=caps", false},
+		{"api override keeps rss", New("https://indexer.net", "key", 1, false).WithAPIPath("/api/v1/api"), rssPath, "https://indexer.net/rss?t=caps", false},
+		{"rss override", New("https://indexer.net", "key", 1, false).WithRSSPath("feeds/rss"), rssPath, "https://indexer.net/feeds/rss?t=caps", false},
+		{"base url is endpoint", New("https://prowlarr.local/12/api", "key", 1, false).WithAPIPath("."), apiPath, "https://prowlarr.local/12/api?t=caps", false},
+		{"absolute override", New("https://indexer.net", "key", 1, false).WithAPIPath("https://other.net/api?x=1"), apiPath, "https://other.net/api?t=caps&x=1", false},
+		{"override query", New("https://indexer.net", "key", 1, false).WithAPIPath("/api?t=ignored"), apiPath, "https://indexer.net/api?t=caps", false},
+		{"invalid base", New("://indexer", "key", 1, false), apiPath, "", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.client.buildURL(vals, tc.path)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDetectAPIPath(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api":
+			w.Write([]byte("<html><body>Not an api</body></html>")) // nolint:errcheck
+		case "/api/v1/api":
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><caps><server title="v1"/></caps>`)) // nolint:errcheck
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	t.Run("detects the first api", func(t *testing.T) {
+		client, path, err := New(ts.URL, "key", 1, false).DetectAPIPath()
+		require.NoError(t, err)
+		require.Equal(t, "/api/v1/api", path)
+
+		caps, err := client.Capabilities()
+		require.NoError(t, err)
+		require.Equal(t, "v1", caps.Server.Title)
+	})
+
+	t.Run("no api", func(t *testing.T) {
+		_, _, err := New(ts.URL, "key", 1, false).DetectAPIPath("/newznab/api", "/api")
+		require.Equal(t, ErrAPINotFound, err)
+	})
+}
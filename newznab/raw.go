@@ -0,0 +1,114 @@
+package newznab
+
+import (
+	"encoding/xml"
+	"strconv"
+	"time"
+)
+
+// XML namespaces used by newznab and torznab feeds
+const (
+	NewznabNamespace = "http://www.newznab.com/DTD/2010/feeds/attributes/"
+	TorznabNamespace = "http://torznab.com/schemas/2015/feed"
+	AtomNamespace    = "http://www.w3.org/2005/Atom"
+)
+
+// Raw returns the feed item for this NZB, the inverse of how search results are read.
+// Attributes are only included when they are set.
+func (n NZB) Raw() RawNZB {
+	raw := RawNZB{
+		Title:       n.Title,
+		Link:        n.DownloadURL,
+		Description: n.Description,
+		Date:        Time{Time: n.PubDate},
+	}
+	raw.GUID.GUID = n.ID
+	if len(n.Category) > 0 {
+		raw.Category.Value = n.Category[0]
+	}
+	raw.Enclosure.URL = n.DownloadURL
+	raw.Enclosure.Length = strconv.FormatInt(n.Size, 10)
+	raw.Enclosure.Type = "application/x-nzb"
+	namespace := NewznabNamespace
+	if n.IsTorrent {
+		raw.Enclosure.Type = "application/x-bittorrent"
+		namespace = TorznabNamespace
+	}
+
+	add := func(name string, value string) {
+		if value != "" {
+			raw.Attributes = append(raw.Attributes, Attribute{
+				XMLName: xml.Name{Space: namespace, Local: "attr"},
+				Name:    name,
+				Value:   value,
+			})
+		}
+	}
+	addInt := func(name string, value int64) {
+		if value != 0 {
+			add(name, strconv.FormatInt(value, 10))
+		}
+	}
+	addDate := func(name string, value time.Time) {
+		if !value.IsZero() {
+			add(name, value.Format(time.RFC1123Z))
+		}
+	}
+
+	for _, category := range n.Category {
+		add("category", category)
+	}
+	addInt("size", n.Size)
+	add("guid", n.ID)
+	addInt("grabs", int64(n.NumGrabs))
+	addInt("comments", int64(n.NumComments))
+	addDate("usenetdate", n.UsenetDate)
+	add("info", n.Info)
+	add("genre", n.Genre)
+	add("resolution", n.Resolution)
+	addInt("password", int64(n.Password))
+	addInt("files", int64(n.NumFiles))
+	add("group", n.Group)
+	add("poster", n.Poster)
+	if n.HasNFO {
+		add("nfo", "1")
+	}
+
+	add("tvdbid", idAttr(n.TVDBID, n.MediaIDs.TVDB))
+	add("rageid", idAttr(n.TVRageID, n.MediaIDs.TVRage))
+	add("tvmazeid", idAttr(n.TVMazeID, n.MediaIDs.TVMaze))
+	season, episode := n.episodeAttrs()
+	add("season", season)
+	add("episode", episode)
+	add("tvtitle", n.TVTitle)
+	addDate("tvairdate", n.AirDate)
+	addInt("rating", int64(n.Rating))
+
+	imdb := n.IMDBID
+	if imdb == "" {
+		imdb = n.MediaIDs.IMDBNumber()
+	}
+	add("imdb", imdb)
+	addInt("tmdbid", int64(n.MediaIDs.TMDB))
+	addInt("traktid", int64(n.MediaIDs.Trakt))
+	addInt("doubanid", int64(n.MediaIDs.Douban))
+	add("imdbtitle", n.IMDBTitle)
+	addInt("imdbyear", int64(n.IMDBYear))
+	if n.IMDBScore != 0 {
+		add("imdbscore", strconv.FormatFloat(float64(n.IMDBScore), 'f', -1, 32))
+	}
+	add("coverurl", n.CoverURL)
+
+	if n.IsTorrent {
+		add("seeders", strconv.Itoa(n.Seeders))
+		add("peers", strconv.Itoa(n.Peers))
+		add("infohash", n.InfoHash)
+		if n.DownloadVolumeFactor != nil {
+			add("downloadvolumefactor", strconv.FormatFloat(*n.DownloadVolumeFactor, 'f', -1, 64))
+		}
+		if n.UploadVolumeFactor != nil {
+			add("uploadvolumefactor", strconv.FormatFloat(*n.UploadVolumeFactor, 'f', -1, 64))
+		}
+	}
+	return raw
+}
@@ -0,0 +1,111 @@
+package newznab
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SearchRequest describes a search, empty fields are left out of the request
+type SearchRequest struct {
+	// Type is the search function, "search", "tvsearch", "movie", "music" or "book". Defaults to "search".
+	Type       string
+	Query      string
+	Categories []int
+	TVRageID   int
+	TVDBID     int
+	TVMazeID   int
+	IMDBID     string
+	// IDs are sent along with the single id fields above, which take precedence
+	IDs     MediaIDs
+	Season  string
+	Episode string
+	Limit   int
+	Offset  int
+	// Extended asks the indexer to include all attributes of every item
+	Extended bool
+	// Params are added to the request as is, for indexer specific parameters
+	Params url.Values
+}
+
+// SearchPage is a single page of search results
+type SearchPage struct {
+	NZBs []NZB
+	// Offset and Total are reported by the indexer, Total is the number of results across all pages
+	Offset int
+	Total  int
+	// Warnings are the values of results that couldn't be parsed, those results are kept with the fields left empty
+	Warnings []ParseWarning
+}
+
+// addWarnings adds the warnings of the result at the given index
+func (p *SearchPage) addWarnings(item int, warnings []ParseWarning) {
+	for _, warning := range warnings {
+		warning.Item = item
+		p.Warnings = append(p.Warnings, warning)
+	}
+}
+
+// Values returns the query parameters for this request, without the api key
+func (r SearchRequest) Values() url.Values {
+	vals := url.Values{}
+	for key, values := range r.Params {
+		vals[key] = append([]string(nil), values...)
+	}
+	t := r.Type
+	if t == "" {
+		t = "search"
+	}
+	vals.Set("t", t)
+	set := func(key string, value string) {
+		if value != "" {
+			vals.Set(key, value)
+		}
+	}
+	setInt := func(key string, value int) {
+		if value != 0 {
+			vals.Set(key, strconv.Itoa(value))
+		}
+	}
+	set("q", r.Query)
+	if len(r.Categories) > 0 {
+		cats := make([]string, 0, len(r.Categories))
+		for _, cat := range r.Categories {
+			cats = append(cats, strconv.Itoa(cat))
+		}
+		vals.Set("cat", strings.Join(cats, ","))
+	}
+	setInt("rid", r.TVRageID)
+	setInt("tvdbid", r.TVDBID)
+	setInt("tvmazeid", r.TVMazeID)
+	set("imdbid", imdbParam(r.IMDBID))
+	for key, values := range r.IDs.params() {
+		if vals.Get(key) == "" {
+			vals[key] = values
+		}
+	}
+	set("season", r.Season)
+	set("ep", r.Episode)
+	setInt("limit", r.Limit)
+	setInt("offset", r.Offset)
+	if r.Extended {
+		vals.Set("extended", "1")
+	}
+	return vals
+}
+
+// Search runs the given request and returns a single page of results
+func (c Client) Search(ctx context.Context, req SearchRequest) (SearchPage, error) {
+	return c.processPageContext(ctx, c.requestValues(req), apiPath)
+}
+
+// requestValues returns the query parameters for a request with the api key and the default categories of the client
+func (c Client) requestValues(req SearchRequest) url.Values {
+	if len(req.Categories) == 0 {
+		req.Categories = c.defaultCategories
+	}
+	vals := req.Values()
+	vals.Set("apikey", c.apikey)
+	return vals
+}
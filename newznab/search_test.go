@@ -0,0 +1,74 @@
+package newznab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	var got *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/">
+<channel>
+<newznab:response offset="50" total="120"/>
+<item><title>Show.S01E02</title><newznab:attr name="guid" value="abc"/></item>
+</channel>
+</rss>`)) // nolint:errcheck
+	}))
+	defer ts.Close()
+
+	client := New(ts.URL, "gibberish", 1234, false)
+
+	t.Run("request parameters", func(t *testing.T) {
+		page, err := client.Search(context.Background(), SearchRequest{
+			Type:       "tvsearch",
+			Query:      "show",
+			Categories: []int{CategoryTVHD, CategoryTVUHD},
+			TVDBID:     1234,
+			Season:     "1",
+			Episode:    "2",
+			Limit:      50,
+			Offset:     50,
+			Extended:   true,
+		})
+		require.NoError(t, err)
+		require.Len(t, page.NZBs, 1)
+		require.Equal(t, "abc", page.NZBs[0].ID)
+		require.Equal(t, 50, page.Offset)
+		require.Equal(t, 120, page.Total)
+
+		params := got.URL.Query()
+		require.Equal(t, "/api", got.URL.Path)
+		require.Equal(t, "tvsearch", params.Get("t"))
+		require.Equal(t, "show", params.Get("q"))
+		require.Equal(t, "5040,5045", params.Get("cat"))
+		require.Equal(t, "1234", params.Get("tvdbid"))
+		require.Equal(t, "1", params.Get("season"))
+		require.Equal(t, "2", params.Get("ep"))
+		require.Equal(t, "50", params.Get("limit"))
+		require.Equal(t, "50", params.Get("offset"))
+		require.Equal(t, "1", params.Get("extended"))
+		require.Equal(t, "gibberish", params.Get("apikey"))
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		got = nil
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := client.Search(ctx, SearchRequest{Query: "show"})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), context.Canceled.Error())
+		require.Nil(t, got, "no request is sent")
+	})
+
+	t.Run("empty fields are left out", func(t *testing.T) {
+		vals := SearchRequest{Query: "show"}.Values()
+		require.Equal(t, "q=show&t=search", vals.Encode())
+	})
+}
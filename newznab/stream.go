@@ -0,0 +1,150 @@
+package newznab
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxErrorBody limits how much of a failed response is read looking for an error element
+const maxErrorBody = 64 << 10
+
+// SearchEach runs the given search and calls fn for every NZB as it's decoded from the response,
+// so large pages are never held in memory at once. An error returned by fn stops the search and is returned.
+// The returned page has the offset, total and parse warnings reported for the feed but no NZBs.
+// Results are always requested as XML, whatever the response format of the client.
+func (c Client) SearchEach(ctx context.Context, req SearchRequest, fn func(NZB) error) (SearchPage, error) {
+	return c.streamFeed(ctx, c.requestValues(req), apiPath, fn)
+}
+
+// streamFeed requests a search or rss feed and decodes its items one at a time
+func (c Client) streamFeed(ctx context.Context, vals url.Values, path string, fn func(NZB) error) (SearchPage, error) {
+	feedURL, err := c.buildURL(vals, path)
+	if err != nil {
+		return SearchPage{}, err
+	}
+	body, err := c.openURL(ctx, feedURL)
+	if err != nil {
+		return SearchPage{}, err
+	}
+	defer body.Close()
+	return c.decodeFeed(body, fn)
+}
+
+func (c Client) openURL(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "http request failed: %s", url)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer res.Body.Close()
+		// Indexers often explain a failed request with an <error> element
+		data, _ := ioutil.ReadAll(io.LimitReader(res.Body, maxErrorBody))
+		var apiErr APIError
+		if xml.Unmarshal(data, &apiErr) == nil {
+			return nil, &apiErr
+		}
+		return nil, errors.Errorf("unexpected status %d from %s", res.StatusCode, url)
+	}
+	return res.Body, nil
+}
+
+// collectFeed decodes a whole feed into a page
+func (c Client) collectFeed(r io.Reader) (SearchPage, error) {
+	var nzbs []NZB
+	page, err := c.decodeFeed(r, func(nzb NZB) error {
+		nzbs = append(nzbs, nzb)
+		return nil
+	})
+	if err != nil {
+		return SearchPage{}, err
+	}
+	page.NZBs = nzbs
+	return page, nil
+}
+
+// decodeFeed walks the tokens of a feed, decoding each <item> on its own.
+// A root <error> element is returned as *APIError, any other root than <rss> fails the page.
+// The decoder accepts the HTML entities, unescaped ampersands and stray tags found in descriptions,
+// values of an item that can't be parsed are left empty and added to the warnings of the page.
+// A feed that breaks off or an item that can't be decoded ends the page with the items decoded so far
+// and a warning.
+func (c Client) decodeFeed(r io.Reader, fn func(NZB) error) (SearchPage, error) {
+	var page SearchPage
+	dec := xml.NewDecoder(r)
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+	depth := 0
+	items := 0
+	sawRoot := false
+	broken := func(field string, err error) (SearchPage, error) {
+		switch errors.Cause(err).(type) {
+		case *xml.SyntaxError, xml.UnmarshalError:
+			if sawRoot {
+				log.WithError(err).Debug("feed ends early, keeping the items decoded so far")
+				page.Warnings = append(page.Warnings, ParseWarning{Item: items, Field: field, Err: err})
+				return page, nil
+			}
+		}
+		return page, errors.Wrapf(err, "failed to decode xml %s", field)
+	}
+	for {
+		token, err := dec.Token()
+		if err == io.EOF && sawRoot {
+			return page, nil
+		}
+		if err != nil {
+			return broken("feed", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch {
+			case depth == 0 && t.Name.Local == "error":
+				var apiErr APIError
+				if err := dec.DecodeElement(&apiErr, &t); err != nil {
+					return page, errors.Wrap(err, "failed to decode xml error")
+				}
+				return page, &apiErr
+			case depth == 0 && t.Name.Local != "rss":
+				return page, errors.Errorf("unexpected root element <%s>, not a newznab feed", t.Name.Local)
+			case t.Name.Local == "response":
+				for _, attr := range t.Attr {
+					switch attr.Name.Local {
+					case "offset":
+						page.Offset, _ = strconv.Atoi(attr.Value)
+					case "total":
+						page.Total, _ = strconv.Atoi(attr.Value)
+					}
+				}
+			case t.Name.Local == "item":
+				var raw RawNZB
+				if err := dec.DecodeElement(&raw, &t); err != nil {
+					return broken("item", err)
+				}
+				nzb, warnings := c.parseNZB(raw)
+				page.addWarnings(items, warnings)
+				items++
+				if err := fn(nzb); err != nil {
+					return page, err
+				}
+				// DecodeElement consumed the end of the item
+				continue
+			}
+			depth++
+			sawRoot = true
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package newznab
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+const largeFixture = "../tests/fixtures/api/apikey_gibberish_cat_5030_ep_1_season_10_t_tvsearch_tvdbid_75682.xml"
+
+func TestSearchEach(t *testing.T) {
+	fixture, err := ioutil.ReadFile(largeFixture)
+	require.NoError(t, err)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("q") {
+		case "error":
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><error code="500" description="Request limit reached"/>`)) // nolint:errcheck
+		case "truncated":
+			w.Write(fixture[:len(fixture)/2]) // nolint:errcheck
+		case "html":
+			w.Write([]byte(`<!DOCTYPE html><html><head><title>Maintenance</title></head><body><p>Back soon</body></html>`)) // nolint:errcheck
+		case "unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`<html><body>Service Unavailable</body></html>`)) // nolint:errcheck
+		case "unauthorized":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><error code="100" description="Incorrect user credentials"/>`)) // nolint:errcheck
+		default:
+			w.Write(fixture) // nolint:errcheck
+		}
+	}))
+	defer ts.Close()
+	client := New(ts.URL, "gibberish", 1234, false)
+
+	t.Run("matches unmarshal", func(t *testing.T) {
+		var feed SearchResponse
+		require.NoError(t, xml.Unmarshal(fixture, &feed))
+
+		var streamed []NZB
+		page, err := client.SearchEach(context.Background(), SearchRequest{Query: "bones"}, func(nzb NZB) error {
+			streamed = append(streamed, nzb)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Empty(t, page.NZBs)
+		require.Equal(t, feed.Channel.Response.Total, page.Total)
+		require.Len(t, streamed, len(feed.Channel.NZBs))
+		for i, raw := range feed.Channel.NZBs {
+			require.Equal(t, client.toNZB(raw), streamed[i])
+		}
+	})
+
+	t.Run("stop early", func(t *testing.T) {
+		stop := errors.New("stop")
+		count := 0
+		_, err := client.SearchEach(context.Background(), SearchRequest{Query: "bones"}, func(nzb NZB) error {
+			count++
+			if count == 3 {
+				return stop
+			}
+			return nil
+		})
+		require.Equal(t, stop, err)
+		require.Equal(t, 3, count)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		var streamed []NZB
+		page, err := client.SearchEach(context.Background(), SearchRequest{Query: "truncated"}, func(nzb NZB) error {
+			streamed = append(streamed, nzb)
+			return nil
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, streamed)
+		require.Len(t, page.Warnings, 1)
+		require.Equal(t, len(streamed), page.Warnings[0].Item)
+
+		collected, err := client.Search(context.Background(), SearchRequest{Query: "truncated"})
+		require.NoError(t, err)
+		require.Equal(t, streamed, collected.NZBs, "the items before the break are kept")
+		require.Equal(t, page.Warnings, collected.Warnings)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		noop := func(NZB) error { return nil }
+		_, err := client.SearchEach(context.Background(), SearchRequest{Query: "error"}, noop)
+		require.EqualError(t, err, "newznab api error 500: Request limit reached")
+
+		for _, query := range []string{"html", "unavailable"} {
+			_, err = client.SearchEach(context.Background(), SearchRequest{Query: query}, noop)
+			require.Error(t, err, query)
+			_, err = client.SearchWithQuery(nil, query, "search")
+			require.Error(t, err, query)
+		}
+		require.Contains(t, err.Error(), "unexpected status 503")
+
+		_, err = client.SearchWithQuery(nil, "unauthorized", "search")
+		require.EqualError(t, err, "newznab api error 100: Incorrect user credentials")
+	})
+}
+
+// largeFeed returns a feed of n items like a page requested with limit=n
+func largeFeed(n int) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/"><channel>`)
+	fmt.Fprintf(&b, `<newznab:response offset="0" total="%d"/>`, n)
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, `<item><title>Show.S01E%02d.720p.HDTV.x264-GRP</title><guid>https://example.com/details/%d</guid>
+<description>%s</description><pubDate>Thu, 04 May 2017 12:00:00 +0000</pubDate>
+<enclosure url="https://example.com/getnzb/%d.nzb" length="1536000000" type="application/x-nzb"/>
+<newznab:attr name="category" value="5040"/><newznab:attr name="size" value="1536000000"/><newznab:attr name="guid" value="%d"/>
+<newznab:attr name="tvdbid" value="1234"/><newznab:attr name="season" value="S01"/><newznab:attr name="episode" value="E%02d"/></item>`,
+			i%100, i, strings.Repeat("description ", 300), i, i, i%100)
+	}
+	b.WriteString(`</channel></rss>`)
+	return b.Bytes()
+}
+
+func benchmarkFixtures(b *testing.B) map[string][]byte {
+	paths, err := filepath.Glob("../tests/fixtures/api/*.xml")
+	require.NoError(b, err)
+	fixtures := map[string][]byte{"limit=1000": largeFeed(1000)}
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		require.NoError(b, err)
+		if len(data) > 50000 {
+			fixtures[filepath.Base(path)] = data
+		}
+	}
+	return fixtures
+}
+
+func BenchmarkDecodeFeed(b *testing.B) {
+	client := New("https://example.com", "gibberish", 1234, false)
+	for name, data := range benchmarkFixtures(b) {
+		data := data
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if _, err := client.decodeFeed(bytes.NewReader(data), func(NZB) error { return nil }); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUnmarshalFeed(b *testing.B) {
+	client := New("https://example.com", "gibberish", 1234, false)
+	for name, data := range benchmarkFixtures(b) {
+		data := data
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				body, err := ioutil.ReadAll(bytes.NewReader(data))
+				if err != nil {
+					b.Fatal(err)
+				}
+				var feed SearchResponse
+				if err := xml.Unmarshal(body, &feed); err != nil {
+					b.Fatal(err)
+				}
+				for _, raw := range feed.Channel.NZBs {
+					client.toNZB(raw)
+				}
+			}
+		})
+	}
+}
@@ -3,9 +3,11 @@ package newznab
 import (
 	"encoding/json"
 	"encoding/xml"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 // NZB represents an NZB found on the index
@@ -23,13 +25,25 @@ type NZB struct {
 
 	SourceEndpoint string `json:"source_endpoint"`
 	SourceAPIKey   string `json:"source_apikey"`
+	SourceName     string `json:"source_name,omitempty"`
 
 	Category []string `json:"category,omitempty"`
 	Info     string   `json:"info,omitempty"`
 	Genre    string   `json:"genre,omitempty"`
 
+	// Categories are the categories of Category with their names and parents
+	Categories []Category `json:"categories,omitempty"`
+
 	Resolution string `json:"resolution,omitempty"`
 
+	// Usenet details, mostly only reported by t=details
+	// Password is 0 when the release isn't passworded, 1 when it contains rar passworded files and 2 when it is passworded
+	Password int    `json:"password,omitempty"`
+	NumFiles int    `json:"num_files,omitempty"`
+	Group    string `json:"group,omitempty"`
+	Poster   string `json:"poster,omitempty"`
+	HasNFO   bool   `json:"has_nfo,omitempty"`
+
 	// TV Specific stuff
 	TVDBID   string `json:"tvdbid,omitempty"`
 	TVRageID string `json:"tvrageid,omitempty"`
@@ -39,6 +53,14 @@ type NZB struct {
 	TVTitle  string `json:"tvtitle,omitempty"`
 	Rating   int    `json:"rating,omitempty"`
 
+	// Numbered episode fields parsed from Season and Episode.
+	// EpisodeNumbers has more than one episode for multi-episode releases, daily shows only have
+	// AirDateEpisode and anime numbered across seasons only has AbsoluteEpisode.
+	SeasonNumber    int       `json:"season_number,omitempty"`
+	EpisodeNumbers  []int     `json:"episode_numbers,omitempty"`
+	AbsoluteEpisode int       `json:"absolute_episode,omitempty"`
+	AirDateEpisode  time.Time `json:"air_date_episode,omitempty"`
+
 	// Movie Specific stuff
 	IMDBID    string  `json:"imdb,omitempty"`
 	IMDBTitle string  `json:"imdbtitle,omitempty"`
@@ -46,16 +68,27 @@ type NZB struct {
 	IMDBScore float32 `json:"imdbscore,omitempty"`
 	CoverURL  string  `json:"coverurl,omitempty"`
 
+	// MediaIDs has the ids of all metadata sites reported for the release, with a normalized imdb id
+	MediaIDs MediaIDs `json:"media_ids,omitempty"`
+
 	// Torznab specific stuff
 	Seeders     int    `json:"seeders,omitempty"`
 	Peers       int    `json:"peers,omitempty"`
 	InfoHash    string `json:"infohash,omitempty"`
 	DownloadURL string `json:"download_url,omitempty"`
 	IsTorrent   bool   `json:"is_torrent,omitempty"`
+	// Volume factors are nil when the tracker doesn't report them
+	DownloadVolumeFactor *float64 `json:"downloadvolumefactor,omitempty"`
+	UploadVolumeFactor   *float64 `json:"uploadvolumefactor,omitempty"`
 }
 
 // Comment represents a user comment left on an NZB record
 type Comment struct {
+	ID     string `json:"id,omitempty"`
+	GUID   string `json:"guid,omitempty"`
+	Author string `json:"author,omitempty"`
+	// Rating is the score given along with the comment by indexers that support it
+	Rating  int       `json:"rating,omitempty"`
 	Title   string    `json:"title,omitempty"`
 	Content string    `json:"content,omitempty"`
 	PubDate time.Time `json:"pub_date,omitempty"`
@@ -67,6 +100,11 @@ func (n NZB) JSONString() string {
 	return string(jsonString)
 }
 
+// IsFreeleech reports whether downloading this torrent doesn't count against the ratio
+func (n NZB) IsFreeleech() bool {
+	return n.DownloadVolumeFactor != nil && *n.DownloadVolumeFactor == 0
+}
+
 // JSONString returns a JSON string representation of this Comment
 func (c Comment) JSONString() string {
 	jsonString, _ := json.MarshalIndent(c, "", "  ")
@@ -76,8 +114,8 @@ func (c Comment) JSONString() string {
 // SearchResponse is a RSS version of the response.
 type SearchResponse struct {
 	Version   string `xml:"version,attr"`
-	ErrorCode int    `xml:"code,attr"`
-	ErrorDesc string `xml:"description,attr"`
+	ErrorCode int    `xml:"code,attr,omitempty"`
+	ErrorDesc string `xml:"description,attr,omitempty"`
 	Channel   struct {
 		Title string `xml:"title"`
 		Link  struct {
@@ -140,11 +178,17 @@ type RawNZB struct {
 		Type   string `xml:"type,attr"`
 	} `xml:"enclosure,omitempty"`
 
-	Attributes []struct {
-		XMLName xml.Name
-		Name    string `xml:"name,attr"`
-		Value   string `xml:"value,attr"`
-	} `xml:"attr"`
+	Attributes []Attribute `xml:"attr"`
+
+	// warnings are the values that couldn't be parsed while decoding
+	warnings []ParseWarning
+}
+
+// Attribute is a single newznab:attr or torznab:attr element of an item
+type Attribute struct {
+	XMLName xml.Name
+	Name    string `xml:"name,attr"`
+	Value   string `xml:"value,attr"`
 }
 
 type Time struct {
@@ -164,6 +208,8 @@ func (t *Time) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	return nil
 }
 
+// UnmarshalXML parses the date with parseDate, a date that can't be parsed is left zero
+// so one odd item doesn't fail the whole feed.
 func (t *Time) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	var raw string
 
@@ -171,13 +217,18 @@ func (t *Time) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	if err != nil {
 		return err
 	}
-	date, err := time.Parse(time.RFC1123Z, raw)
-
+	if strings.TrimSpace(raw) == "" {
+		*t = Time{}
+		return nil
+	}
+	date, err := parseDate(raw)
 	if err != nil {
-		return err
+		log.WithError(err).WithField("date", raw).Debug("failed to parse xml date")
+		*t = Time{}
+		return nil
 	}
 
-	*t = Time{date}
+	*t = Time{Time: date}
 	return nil
 
 }
@@ -201,15 +252,62 @@ type Capabilities struct {
 		} `xml:"movie-search" json:"movie_search,omitempty"`
 	} `xml:"searching" json:"searching,omitempty"`
 	Categories struct {
-		Category []struct {
-			ID     string `xml:"id,attr" json:"id,omitempty"`
-			Name   string `xml:"name,attr" json:"name,omitempty"`
-			Subcat []struct {
-				ID   string `xml:"id,attr" json:"id,omitempty"`
-				Name string `xml:"name,attr" json:"name,omitempty"`
-			} `xml:"subcat" json:"subcat,omitempty"`
-		} `xml:"category" json:"category,omitempty"`
+		Category []CapsCategory `xml:"category" json:"category,omitempty"`
 	} `xml:"categories" json:"categories,omitempty"`
+	// Registration is nil when the indexer doesn't report whether it allows sign-up through the api
+	Registration *CapsRegistration `xml:"registration" json:"registration,omitempty"`
+}
+
+// CapsRegistration tells whether new accounts can be created with t=register
+type CapsRegistration struct {
+	Available string `xml:"available,attr" json:"available,omitempty"`
+	Open      string `xml:"open,attr" json:"open,omitempty"`
+}
+
+// CapsCategory is a top level category listed in the capabilities
+type CapsCategory struct {
+	ID     string       `xml:"id,attr" json:"id,omitempty"`
+	Name   string       `xml:"name,attr" json:"name,omitempty"`
+	Subcat []CapsSubcat `xml:"subcat" json:"subcat,omitempty"`
+}
+
+// CapsSubcat is a subcategory listed in the capabilities
+type CapsSubcat struct {
+	ID   string `xml:"id,attr" json:"id,omitempty"`
+	Name string `xml:"name,attr" json:"name,omitempty"`
+}
+
+// Supports reports whether the given search type ("search", "tvsearch" or "movie") is available
+// and accepts the given parameter. A search type listing no supported params is assumed to accept q.
+func (c Capabilities) Supports(searchType string, param string) bool {
+	var available, params string
+	switch searchType {
+	case "search":
+		available, params = c.Searching.Search.Available, c.Searching.Search.SupportedParams
+	case "tvsearch":
+		available, params = c.Searching.TvSearch.Available, c.Searching.TvSearch.SupportedParams
+	case "movie":
+		available, params = c.Searching.MovieSearch.Available, c.Searching.MovieSearch.SupportedParams
+	default:
+		return false
+	}
+	if available != "yes" {
+		return false
+	}
+	if strings.TrimSpace(params) == "" {
+		return param == "q"
+	}
+	for _, supported := range strings.Split(params, ",") {
+		if strings.TrimSpace(supported) == param {
+			return true
+		}
+	}
+	return false
+}
+
+// RegistrationOpen reports whether the indexer accepts new accounts with t=register
+func (c Capabilities) RegistrationOpen() bool {
+	return c.Registration != nil && c.Registration.Available == "yes" && c.Registration.Open == "yes"
 }
 
 type Details struct {
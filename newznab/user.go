@@ -0,0 +1,136 @@
+package newznab
+
+import (
+	"context"
+	"encoding/xml"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// UserInfo is the account of the user as reported by t=user
+type UserInfo struct {
+	Username         string `json:"username,omitempty"`
+	Role             string `json:"role,omitempty"`
+	Grabs            int    `json:"grabs,omitempty"`
+	APIRequests      int    `json:"api_requests,omitempty"`
+	APILimit         int    `json:"api_limit,omitempty"`
+	DownloadRequests int    `json:"download_requests,omitempty"`
+	DownloadLimit    int    `json:"download_limit,omitempty"`
+	// Expires is when the role of the user ends, it's zero for roles that don't expire
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// Registration is the account created by t=register
+type Registration struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	APIKey   string `json:"apikey,omitempty"`
+	// UserID is only reported by some indexers
+	UserID int `json:"user_id,omitempty"`
+}
+
+// UserInfo returns the role, usage and limits of the account with t=user
+func (c Client) UserInfo(ctx context.Context) (UserInfo, error) {
+	attrs, err := c.attrCall(ctx, "user", url.Values{
+		"t":      []string{"user"},
+		"apikey": []string{c.apikey},
+	})
+	if err != nil {
+		return UserInfo{}, err
+	}
+	info := UserInfo{
+		Username:         attrs.get("username"),
+		Role:             attrs.get("role"),
+		Grabs:            attrs.int("grabs"),
+		APIRequests:      attrs.int("apirequests"),
+		APILimit:         attrs.int("apilimit", "apirequestslimit"),
+		DownloadRequests: attrs.int("downloadrequests"),
+		DownloadLimit:    attrs.int("downloadlimit", "downloadrequestslimit"),
+	}
+	if expires := attrs.get("expires", "rolechangedate"); expires != "" {
+		if info.Expires, err = parseDate(expires); err != nil {
+			log.WithError(err).WithField("expires", expires).Debug("failed to parse role expiry")
+		}
+	}
+	return info, nil
+}
+
+// Register creates an account for the given email with t=register.
+// It checks the capabilities first and fails with ErrorRegistrationClosed when the indexer doesn't allow sign-up.
+func (c Client) Register(ctx context.Context, email string) (Registration, error) {
+	caps, err := c.caps(ctx, url.Values{"t": []string{"caps"}})
+	if err != nil {
+		return Registration{}, err
+	}
+	if !caps.RegistrationOpen() {
+		return Registration{}, NewAPIError(ErrorRegistrationClosed)
+	}
+	attrs, err := c.attrCall(ctx, "register", url.Values{
+		"t":     []string{"register"},
+		"email": []string{email},
+	})
+	if err != nil {
+		return Registration{}, err
+	}
+	reg := Registration{
+		Username: attrs.get("username"),
+		Password: attrs.get("password"),
+		APIKey:   attrs.get("apikey"),
+		UserID:   attrs.int("userid", "id"),
+	}
+	if reg.APIKey == "" {
+		return Registration{}, errors.New("registration response has no api key")
+	}
+	return reg, nil
+}
+
+// attrCall calls an api function answering with a single element like <user username="..."/> and returns its attributes
+func (c Client) attrCall(ctx context.Context, element string, vals url.Values) (xmlAttrs, error) {
+	callURL, err := c.buildURL(vals, apiPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.getURLContext(ctx, callURL)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		XMLName xml.Name
+		Attrs   []xml.Attr `xml:",any,attr"`
+	}
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal %s xml data", element)
+	}
+	attrs := xmlAttrs(resp.Attrs)
+	switch resp.XMLName.Local {
+	case element:
+		return attrs, nil
+	case "error":
+		return nil, &APIError{Code: attrs.int("code"), Description: attrs.get("description")}
+	}
+	return nil, errors.Errorf("unexpected <%s> response to t=%s", resp.XMLName.Local, vals.Get("t"))
+}
+
+type xmlAttrs []xml.Attr
+
+// get returns the value of the first of the given attributes that is set
+func (a xmlAttrs) get(names ...string) string {
+	for _, name := range names {
+		for _, attr := range a {
+			if strings.EqualFold(attr.Name.Local, name) && attr.Value != "" {
+				return attr.Value
+			}
+		}
+	}
+	return ""
+}
+
+func (a xmlAttrs) int(names ...string) int {
+	value, _ := strconv.Atoi(a.get(names...))
+	return value
+}
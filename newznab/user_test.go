@@ -0,0 +1,91 @@
+package newznab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserInfo(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "user", r.URL.Query().Get("t"))
+		switch r.URL.Query().Get("apikey") {
+		case "gibberish":
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<user username="someone" grabs="12" role="VIP" apirequests="37" downloadrequests="5" apilimit="1000" downloadlimit="100" rolechangedate="2027-01-31 12:00:00"/>`)) // nolint:errcheck
+		case "rss":
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel/></rss>`)) // nolint:errcheck
+		default:
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><error code="100" description="Incorrect user credentials"/>`)) // nolint:errcheck
+		}
+	}))
+	defer ts.Close()
+
+	t.Run("account", func(t *testing.T) {
+		user, err := New(ts.URL, "gibberish", 1234, false).UserInfo(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, UserInfo{
+			Username:         "someone",
+			Role:             "VIP",
+			Grabs:            12,
+			APIRequests:      37,
+			APILimit:         1000,
+			DownloadRequests: 5,
+			DownloadLimit:    100,
+			Expires:          time.Date(2027, 1, 31, 12, 0, 0, 0, time.UTC),
+		}, user)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := New(ts.URL, "wrong", 1234, false).UserInfo(context.Background())
+		require.EqualError(t, err, "newznab api error 100: Incorrect user credentials")
+
+		_, err = New(ts.URL, "rss", 1234, false).UserInfo(context.Background())
+		require.EqualError(t, err, "unexpected <rss> response to t=user")
+	})
+}
+
+func TestRegister(t *testing.T) {
+	registration := `<registration available="yes" open="yes"/>`
+	response := `<register username="someone" password="secret" apikey="new-key" userid="42"/>`
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("t") {
+		case "caps":
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><caps><server title="test"/>` + registration + `</caps>`)) // nolint:errcheck
+		case "register":
+			require.Equal(t, "someone@example.com", r.URL.Query().Get("email"))
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>` + response)) // nolint:errcheck
+		}
+	}))
+	defer ts.Close()
+	client := New(ts.URL, "", 0, false)
+
+	t.Run("open", func(t *testing.T) {
+		reg, err := client.Register(context.Background(), "someone@example.com")
+		require.NoError(t, err)
+		require.Equal(t, Registration{Username: "someone", Password: "secret", APIKey: "new-key", UserID: 42}, reg)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		response = `<error code="105" description="Invalid registration (Email Address Taken)"/>`
+		_, err := client.Register(context.Background(), "someone@example.com")
+		require.EqualError(t, err, "newznab api error 105: Invalid registration (Email Address Taken)")
+
+		response = `<register username="someone"/>`
+		_, err = client.Register(context.Background(), "someone@example.com")
+		require.EqualError(t, err, "registration response has no api key")
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		for _, registration = range []string{`<registration available="yes" open="no"/>`, ""} {
+			_, err := client.Register(context.Background(), "someone@example.com")
+			apiErr, ok := err.(*APIError)
+			require.True(t, ok)
+			require.Equal(t, ErrorRegistrationClosed, apiErr.Code)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package newznab
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ParseWarning is a value of a result that couldn't be parsed.
+// The result is kept with the field left empty.
+type ParseWarning struct {
+	// Item is the index of the result in the page
+	Item int
+	// Field is the element or attribute the value came from, attributes are prefixed with "attr:"
+	Field string
+	Value string
+	Err   error
+}
+
+func (w ParseWarning) Error() string {
+	return fmt.Sprintf("item %d: %s %q: %v", w.Item, w.Field, w.Value, w.Err)
+}
+
+// UnmarshalXML decodes an item without failing on values that can't be parsed,
+// they are left empty and reported as warnings of the page.
+func (n *RawNZB) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type rawNZB RawNZB
+	var item struct {
+		rawNZB
+		Size string `xml:"size,omitempty"`
+		Date string `xml:"pubDate,omitempty"`
+		GUID struct {
+			GUID        string `xml:",chardata"`
+			IsPermaLink string `xml:"isPermaLink,attr"`
+		} `xml:"guid,omitempty"`
+	}
+	if err := d.DecodeElement(&item, &start); err != nil {
+		return err
+	}
+	*n = RawNZB(item.rawNZB)
+	n.GUID.GUID = item.GUID.GUID
+	if value := strings.TrimSpace(item.GUID.IsPermaLink); value != "" {
+		var err error
+		if n.GUID.IsPermaLink, err = strconv.ParseBool(value); err != nil {
+			n.warn("guid isPermaLink", value, err)
+		}
+	}
+	if value := strings.TrimSpace(item.Size); value != "" {
+		var err error
+		if n.Size, err = strconv.ParseInt(value, 10, 64); err != nil {
+			n.Size = 0
+			n.warn("size", value, err)
+		}
+	}
+	n.setDate(item.Date)
+	return nil
+}
+
+// setDate parses the pubDate of an item, a date that can't be parsed is left zero
+func (n *RawNZB) setDate(value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	date, err := parseDate(value)
+	if err != nil {
+		n.warn("pubDate", value, err)
+		return
+	}
+	n.Date = Time{Time: date}
+}
+
+func (n *RawNZB) warn(field, value string, err error) {
+	n.warnings = append(n.warnings, ParseWarning{Field: field, Value: value, Err: err})
+}
+
+// attrParser reads the values of newznab attributes, collecting a warning for each value that can't be parsed
+type attrParser struct {
+	warnings []ParseWarning
+}
+
+func (p *attrParser) warn(attr Attribute, err error) {
+	log.WithError(err).WithFields(log.Fields{
+		"name":  attr.Name,
+		"value": attr.Value,
+	}).Debug("failed to parse attribute")
+	p.warnings = append(p.warnings, ParseWarning{Field: "attr:" + attr.Name, Value: attr.Value, Err: err})
+}
+
+func (p *attrParser) int(attr Attribute, bitSize int) int64 {
+	value := strings.TrimSpace(attr.Value)
+	if value == "" {
+		return 0
+	}
+	parsed, err := strconv.ParseInt(value, 10, bitSize)
+	if err != nil {
+		p.warn(attr, err)
+		return 0
+	}
+	return parsed
+}
+
+func (p *attrParser) float(attr Attribute, bitSize int) (float64, bool) {
+	value := strings.TrimSpace(attr.Value)
+	if value == "" {
+		return 0, false
+	}
+	parsed, err := strconv.ParseFloat(value, bitSize)
+	if err != nil {
+		p.warn(attr, err)
+		return 0, false
+	}
+	return parsed, true
+}
+
+func (p *attrParser) date(attr Attribute) time.Time {
+	value := strings.TrimSpace(attr.Value)
+	if value == "" {
+		return time.Time{}
+	}
+	date, err := parseDate(value)
+	if err != nil {
+		p.warn(attr, err)
+		return time.Time{}
+	}
+	return date
+}
@@ -0,0 +1,92 @@
+package newznab
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const brokenFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/"><channel>
+<newznab:response offset="0" total="3"/>
+<item><title>good</title><guid isPermaLink="true">https://example.com/details/good</guid>
+<pubDate>Mon, 02 Jan 2006 15:04:05 +0000</pubDate>
+<newznab:attr name="guid" value="good"/><newznab:attr name="size" value="1024"/><newznab:attr name="grabs" value="5"/></item>
+<item><title>broken</title><guid isPermaLink="yes">https://example.com/details/broken</guid><size>1,024</size>
+<pubDate>last week</pubDate><description>Tom &amp; Jerry&nbsp;&copy; R&D<br>line</description>
+<newznab:attr name="guid" value="broken"/><newznab:attr name="grabs" value="many"/>
+<newznab:attr name="imdbscore" value="N/A"/><newznab:attr name="usenetdate" value="soon"/>
+<newznab:attr name="downloadvolumefactor" value="free"/></item>
+<item><title>empty</title><size></size><newznab:attr name="guid" value="empty"/><newznab:attr name="grabs" value=""/></item>
+</channel></rss>`
+
+func TestParseWarnings(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("o") == "json" {
+			w.Write([]byte(`{"channel": {"item": [{"title": "ok"}, {"title": "bad", "size": "big", "pubDate": "never", "attr": {"grabs": "x"}}]}}`)) // nolint:errcheck
+			return
+		}
+		w.Write([]byte(brokenFeed)) // nolint:errcheck
+	}))
+	defer ts.Close()
+	client := New(ts.URL, "gibberish", 1234, false)
+
+	t.Run("xml", func(t *testing.T) {
+		page, err := client.Search(context.Background(), SearchRequest{Query: "broken"})
+		require.NoError(t, err)
+		require.Len(t, page.NZBs, 3)
+
+		good := page.NZBs[0]
+		require.Equal(t, "good", good.ID)
+		require.Equal(t, int64(1024), good.Size)
+		require.Equal(t, 5, good.NumGrabs)
+		require.True(t, time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC).Equal(good.PubDate))
+
+		broken := page.NZBs[1]
+		require.Equal(t, "broken", broken.ID)
+		require.Equal(t, "Tom & Jerry\u00a0\u00a9 R&D", broken.Description, "text after a stray tag is lost but the item is kept")
+		require.Zero(t, broken.Size)
+		require.Zero(t, broken.NumGrabs)
+		require.True(t, broken.PubDate.IsZero())
+		require.True(t, broken.UsenetDate.IsZero())
+		require.Nil(t, broken.DownloadVolumeFactor)
+
+		var fields []string
+		for _, warning := range page.Warnings {
+			require.Equal(t, 1, warning.Item, warning.Error())
+			require.Error(t, warning.Err)
+			fields = append(fields, warning.Field)
+		}
+		require.Equal(t, []string{
+			"guid isPermaLink", "size", "pubDate",
+			"attr:grabs", "attr:imdbscore", "attr:usenetdate", "attr:downloadvolumefactor",
+		}, fields)
+		require.Equal(t, `item 1: attr:grabs "many": strconv.ParseInt: parsing "many": invalid syntax`, page.Warnings[3].Error())
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		count := 0
+		page, err := client.SearchEach(context.Background(), SearchRequest{Query: "broken"}, func(NZB) error {
+			count++
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, count)
+		require.Len(t, page.Warnings, 7)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		page, err := client.WithResponseFormat(FormatJSON).Search(context.Background(), SearchRequest{Query: "broken"})
+		require.NoError(t, err)
+		require.Len(t, page.NZBs, 2)
+		require.Len(t, page.Warnings, 3)
+		for _, warning := range page.Warnings {
+			require.Equal(t, 1, warning.Item)
+		}
+		require.Equal(t, "attr:grabs", page.Warnings[2].Field)
+	})
+}
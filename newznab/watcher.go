@@ -0,0 +1,233 @@
+package newznab
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// WatcherOptions configures a Watcher
+type WatcherOptions struct {
+	// Categories to watch
+	Categories []int
+	// Num is the number of items requested per page, defaults to 50
+	Num int
+	// Interval between polls, defaults to 15 minutes
+	Interval time.Duration
+	// Jitter adds a random delay of up to this duration to every interval
+	Jitter time.Duration
+	// MaxPages limits how far back a single poll pages to catch up, defaults to 10
+	MaxPages int
+	// LastID and Since resume watching after the given NZB id or publish date.
+	// When both are empty the first poll only records the current items without sending them.
+	LastID string
+	Since  time.Time
+	// Checkpoints persists acknowledged progress under CheckpointKey so watching resumes after a restart.
+	// A stored checkpoint takes precedence over LastID and Since.
+	Checkpoints   CheckpointStore
+	CheckpointKey string
+	// OnError is called when a poll fails, the watcher keeps polling afterwards
+	OnError func(error)
+}
+
+// Watcher polls the RSS feed of an indexer and sends only the releases it hasn't seen before
+type Watcher struct {
+	client Client
+	opts   WatcherOptions
+
+	seen   map[string]bool
+	order  []string
+	newest time.Time
+	primed bool
+	loaded bool
+}
+
+// NewWatcher returns a new Watcher for the given client
+func NewWatcher(c Client, opts WatcherOptions) *Watcher {
+	if opts.Num <= 0 {
+		opts.Num = 50
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 15 * time.Minute
+	}
+	if opts.MaxPages <= 0 {
+		opts.MaxPages = 10
+	}
+	w := &Watcher{
+		client: c,
+		opts:   opts,
+		seen:   map[string]bool{},
+		newest: opts.Since,
+	}
+	if opts.LastID != "" {
+		w.remember(opts.LastID)
+	}
+	w.primed = opts.LastID != "" || !opts.Since.IsZero()
+	return w
+}
+
+// Watch polls until ctx is cancelled and sends new NZBs on the returned channel, oldest first.
+// The channel is closed once the watcher stops. When checkpoints are configured, call Ack for
+// every NZB once it has been handled.
+func (w *Watcher) Watch(ctx context.Context) <-chan NZB {
+	out := make(chan NZB)
+	go func() {
+		defer close(out)
+		for {
+			nzbs, err := w.Poll(ctx)
+			if ctx.Err() != nil {
+				// Stopping cancels the poll in flight, that isn't an error worth reporting
+				return
+			}
+			if err != nil {
+				if w.opts.OnError != nil {
+					w.opts.OnError(err)
+				}
+				log.WithError(err).Debug("failed to poll rss feed")
+			}
+			for _, nzb := range nzbs {
+				select {
+				case out <- nzb:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			timer := time.NewTimer(w.nextInterval())
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Poll fetches the feed once and returns the NZBs that are new since the last poll, oldest first.
+// Pages are fetched backwards until a known item is reached, an item repeated on a later page is sent once.
+func (w *Watcher) Poll(ctx context.Context) ([]NZB, error) {
+	if err := w.loadCheckpoint(); err != nil {
+		return nil, err
+	}
+
+	var fresh []NZB
+	batch := map[string]bool{}
+	for page := 0; page < w.opts.MaxPages; page++ {
+		partition, err := w.client.loadRSSPage(ctx, w.opts.Categories, w.opts.Num, page*w.opts.Num)
+		if err != nil {
+			return nil, err
+		}
+		reachedKnown := false
+		for _, nzb := range partition {
+			if w.known(nzb) {
+				reachedKnown = true
+				break
+			}
+			// New releases shift the feed while paging, so the end of a page can show up again on the next one
+			if batch[watchKey(nzb)] {
+				continue
+			}
+			batch[watchKey(nzb)] = true
+			fresh = append(fresh, nzb)
+		}
+		// Without any known state a single page is enough to prime the watcher
+		if reachedKnown || len(partition) < w.opts.Num || !w.primed {
+			break
+		}
+	}
+
+	// Feeds list the newest items first
+	for i, j := 0, len(fresh)-1; i < j; i, j = i+1, j-1 {
+		fresh[i], fresh[j] = fresh[j], fresh[i]
+	}
+	for _, nzb := range fresh {
+		w.remember(watchKey(nzb))
+		if nzb.PubDate.After(w.newest) {
+			w.newest = nzb.PubDate
+		}
+	}
+
+	if !w.primed {
+		w.primed = true
+		// Record the starting point right away so a restart doesn't prime again and skip releases
+		if w.opts.Checkpoints != nil && len(fresh) > 0 {
+			if err := w.Ack(fresh[len(fresh)-1]); err != nil {
+				return nil, errors.Wrap(err, "failed to save checkpoint")
+			}
+		}
+		return nil, nil
+	}
+	return fresh, nil
+}
+
+// Ack marks the given NZB and every NZB sent before it as handled and commits the checkpoint
+func (w *Watcher) Ack(nzb NZB) error {
+	if w.opts.Checkpoints == nil {
+		return nil
+	}
+	return w.opts.Checkpoints.Save(w.opts.CheckpointKey, Checkpoint{
+		LastID:    watchKey(nzb),
+		PubDate:   nzb.PubDate,
+		UpdatedAt: time.Now(),
+	})
+}
+
+func (w *Watcher) loadCheckpoint() error {
+	if w.loaded || w.opts.Checkpoints == nil {
+		return nil
+	}
+	checkpoint, err := w.opts.Checkpoints.Load(w.opts.CheckpointKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to load checkpoint")
+	}
+	w.loaded = true
+	if checkpoint.IsZero() {
+		return nil
+	}
+	if checkpoint.LastID != "" {
+		w.remember(checkpoint.LastID)
+	}
+	w.newest = checkpoint.PubDate
+	w.primed = true
+	return nil
+}
+
+func (w *Watcher) known(nzb NZB) bool {
+	if w.seen[watchKey(nzb)] {
+		return true
+	}
+	return !w.newest.IsZero() && !nzb.PubDate.IsZero() && nzb.PubDate.Before(w.newest)
+}
+
+// remember records a key, only keeping enough keys to recognise the last few pages
+func (w *Watcher) remember(key string) {
+	if w.seen[key] {
+		return
+	}
+	w.seen[key] = true
+	w.order = append(w.order, key)
+	if max := w.opts.Num * w.opts.MaxPages; len(w.order) > max {
+		delete(w.seen, w.order[0])
+		w.order = w.order[1:]
+	}
+}
+
+func (w *Watcher) nextInterval() time.Duration {
+	if w.opts.Jitter <= 0 {
+		return w.opts.Interval
+	}
+	return w.opts.Interval + time.Duration(rand.Int63n(int64(w.opts.Jitter)))
+}
+
+// watchKey identifies an NZB by its id, falling back to the download URL for feeds without ids
+func watchKey(nzb NZB) string {
+	if nzb.ID != "" {
+		return nzb.ID
+	}
+	return nzb.DownloadURL
+}
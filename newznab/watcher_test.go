@@ -0,0 +1,170 @@
+package newznab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFeed serves an RSS feed of generated items, newest first
+type fakeFeed struct {
+	sync.Mutex
+	count int
+	start time.Time
+}
+
+func (f *fakeFeed) add(n int) {
+	f.Lock()
+	defer f.Unlock()
+	f.count += n
+}
+
+func (f *fakeFeed) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	num, _ := strconv.Atoi(r.URL.Query().Get("num"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/"><channel>`)
+	for i := f.count - 1 - offset; i >= 0 && i >= f.count-offset-num; i-- {
+		fmt.Fprintf(w, `<item><title>item-%d</title><pubDate>%s</pubDate><newznab:attr name="guid" value="guid-%d"/></item>`,
+			i, f.start.Add(time.Duration(i)*time.Minute).Format(time.RFC1123Z), i)
+	}
+	fmt.Fprint(w, `</channel></rss>`)
+}
+
+func TestWatcher(t *testing.T) {
+	feed := &fakeFeed{start: time.Date(2017, 5, 4, 12, 0, 0, 0, time.UTC)}
+	ts := httptest.NewServer(feed)
+	defer ts.Close()
+	client := New(ts.URL, "gibberish", 1234, false)
+
+	t.Run("poll", func(t *testing.T) {
+		feed.add(30)
+		w := NewWatcher(client, WatcherOptions{Num: 10, MaxPages: 5})
+
+		t.Run("first poll primes", func(t *testing.T) {
+			nzbs, err := w.Poll(context.Background())
+			require.NoError(t, err)
+			require.Empty(t, nzbs)
+		})
+
+		t.Run("nothing new", func(t *testing.T) {
+			nzbs, err := w.Poll(context.Background())
+			require.NoError(t, err)
+			require.Empty(t, nzbs)
+		})
+
+		t.Run("new items in chronological order", func(t *testing.T) {
+			feed.add(3)
+			nzbs, err := w.Poll(context.Background())
+			require.NoError(t, err)
+			require.Len(t, nzbs, 3)
+			require.Equal(t, "guid-30", nzbs[0].ID)
+			require.Equal(t, "guid-32", nzbs[2].ID)
+		})
+
+		t.Run("pages back to known items", func(t *testing.T) {
+			feed.add(25)
+			nzbs, err := w.Poll(context.Background())
+			require.NoError(t, err)
+			require.Len(t, nzbs, 25)
+			require.Equal(t, "guid-33", nzbs[0].ID)
+			require.Equal(t, "guid-57", nzbs[24].ID)
+		})
+	})
+
+	t.Run("resume after last id", func(t *testing.T) {
+		w := NewWatcher(client, WatcherOptions{Num: 10, LastID: "guid-50"})
+		nzbs, err := w.Poll(context.Background())
+		require.NoError(t, err)
+		require.Len(t, nzbs, 7)
+		require.Equal(t, "guid-51", nzbs[0].ID)
+	})
+
+	t.Run("resume since date", func(t *testing.T) {
+		w := NewWatcher(client, WatcherOptions{Num: 10, Since: feed.start.Add(55 * time.Minute)})
+		nzbs, err := w.Poll(context.Background())
+		require.NoError(t, err)
+		require.Len(t, nzbs, 3)
+		require.Equal(t, "guid-55", nzbs[0].ID)
+	})
+
+	t.Run("items repeated on the next page are sent once", func(t *testing.T) {
+		shifting := &fakeFeed{start: feed.start, count: 20}
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// A release added between requests pushes the last item of a page onto the next one
+			if r.URL.Query().Get("offset") != "0" {
+				shifting.add(1)
+			}
+			shifting.ServeHTTP(w, r)
+		}))
+		defer ts.Close()
+
+		w := NewWatcher(New(ts.URL, "gibberish", 1234, false), WatcherOptions{Num: 10, LastID: "guid-0"})
+		nzbs, err := w.Poll(context.Background())
+		require.NoError(t, err)
+		require.Len(t, nzbs, 19)
+		seen := map[string]bool{}
+		for _, nzb := range nzbs {
+			require.False(t, seen[nzb.ID], nzb.ID)
+			seen[nzb.ID] = true
+		}
+	})
+
+	t.Run("poll with cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := NewWatcher(client, WatcherOptions{Num: 10}).Poll(ctx)
+		require.Error(t, err)
+	})
+
+	t.Run("watch until cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		w := NewWatcher(client, WatcherOptions{Num: 10, Interval: 10 * time.Millisecond, Jitter: 5 * time.Millisecond})
+		out := w.Watch(ctx)
+
+		time.Sleep(50 * time.Millisecond)
+		feed.add(2)
+		require.Equal(t, "guid-58", (<-out).ID)
+		require.Equal(t, "guid-59", (<-out).ID)
+
+		cancel()
+		for range out {
+		}
+	})
+
+	t.Run("stopping is not an error", func(t *testing.T) {
+		started := make(chan struct{}, 1)
+		hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started <- struct{}{}
+			<-r.Context().Done()
+		}))
+		defer hanging.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var errs []error
+		w := NewWatcher(New(hanging.URL, "gibberish", 1234, false), WatcherOptions{OnError: func(err error) {
+			errs = append(errs, err)
+		}})
+		out := w.Watch(ctx)
+		<-started
+		cancel()
+		for range out {
+		}
+		require.Empty(t, errs, "the poll canceled on shutdown is not reported")
+	})
+
+	t.Run("rss until id stops on empty page", func(t *testing.T) {
+		results, err := client.LoadRSSFeedUntilNZBID(nil, 50, "does-not-exist", 0)
+		require.NoError(t, err)
+		require.Len(t, results, 60)
+	})
+}
@@ -0,0 +1,464 @@
+// Package newznabtest provides a configurable in-process newznab indexer for testing code that uses the newznab client.
+package newznabtest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/mrobinsn/go-newznab/server"
+)
+
+// Defaults used by NewIndexer
+const (
+	DefaultAPIKey = "test-api-key"
+	DefaultUserID = 1
+)
+
+// Fault is an error injected into a request
+type Fault struct {
+	// Delay holds the response back for this duration
+	Delay time.Duration
+	// Status responds with this HTTP status code and an empty body
+	Status int
+	// APIError responds with a newznab <error> with this code
+	APIError int
+	// Malformed responds with a truncated XML document
+	Malformed bool
+}
+
+// Request is a request received by the indexer
+type Request struct {
+	Path   string
+	Params url.Values
+}
+
+// Indexer is a fake newznab indexer running on a local HTTP server
+type Indexer struct {
+	*httptest.Server
+	APIKey string
+	UserID int
+
+	mu       sync.Mutex
+	nzbs     []newznab.NZB
+	caps     newznab.Capabilities
+	comments map[string][]newznab.Comment
+	carts    map[string][]string
+	accounts map[string]newznab.Registration
+	payloads map[string][]byte
+	faults   []Fault
+	latency  time.Duration
+	requests []Request
+}
+
+// NewIndexer starts a new fake indexer accepting DefaultAPIKey. Close it when done.
+func NewIndexer() *Indexer {
+	idx := &Indexer{
+		APIKey:   DefaultAPIKey,
+		UserID:   DefaultUserID,
+		caps:     DefaultCapabilities(),
+		comments: map[string][]newznab.Comment{},
+		carts:    map[string][]string{},
+		accounts: map[string]newznab.Registration{},
+		payloads: map[string][]byte{},
+	}
+	handler := server.New(backend{idx}, server.Options{
+		Title: "newznabtest",
+		Authenticate: func(apikey string) error {
+			idx.mu.Lock()
+			defer idx.mu.Unlock()
+			if _, registered := idx.accounts[apikey]; apikey != idx.APIKey && !registered {
+				return newznab.NewAPIError(newznab.ErrorIncorrectCredentials)
+			}
+			return nil
+		},
+	})
+	idx.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if idx.intercept(w, r) {
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	return idx
+}
+
+// Client returns a client configured for this indexer
+func (idx *Indexer) Client() newznab.Client {
+	return newznab.New(idx.URL, idx.APIKey, idx.UserID, false)
+}
+
+// AddNZBs seeds the indexer with the given items, an ID is generated for items without one
+func (idx *Indexer) AddNZBs(nzbs ...newznab.NZB) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	for _, nzb := range nzbs {
+		if nzb.ID == "" {
+			nzb.ID = "nzb-" + strconv.Itoa(len(idx.nzbs)+1)
+		}
+		idx.nzbs = append(idx.nzbs, nzb)
+	}
+}
+
+// SetCapabilities replaces the capabilities returned by t=caps
+func (idx *Indexer) SetCapabilities(caps newznab.Capabilities) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.caps = caps
+}
+
+// AddComments adds comments to the item with the given id
+func (idx *Indexer) AddComments(id string, comments ...newznab.Comment) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.comments[id] = append(idx.comments[id], comments...)
+}
+
+// Cart returns the ids of the items in the cart of the given api key
+func (idx *Indexer) Cart(apikey string) []string {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return append([]string(nil), idx.carts[apikey]...)
+}
+
+// SetPayload sets the NZB file returned by t=get for the given id
+func (idx *Indexer) SetPayload(id string, data []byte) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.payloads[id] = data
+}
+
+// FailNext queues faults, each one is applied to a single upcoming request
+func (idx *Indexer) FailNext(faults ...Fault) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.faults = append(idx.faults, faults...)
+}
+
+// SetLatency delays every response by the given duration
+func (idx *Indexer) SetLatency(latency time.Duration) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.latency = latency
+}
+
+// Requests returns all requests received so far
+func (idx *Indexer) Requests() []Request {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return append([]Request(nil), idx.requests...)
+}
+
+// LastRequest returns the most recent request, or a zero Request if there was none
+func (idx *Indexer) LastRequest() Request {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if len(idx.requests) == 0 {
+		return Request{}
+	}
+	return idx.requests[len(idx.requests)-1]
+}
+
+// RequireLastQuery fails the test unless the most recent request carried all of the given parameters
+func (idx *Indexer) RequireLastQuery(t testing.TB, want map[string]string) {
+	t.Helper()
+	last := idx.LastRequest()
+	for key, value := range want {
+		if got := last.Params.Get(key); got != value {
+			t.Fatalf("expected query parameter %s=%q, got %q in %s?%s", key, value, got, last.Path, last.Params.Encode())
+		}
+	}
+}
+
+// intercept records the request and applies latency and faults, it reports whether the response was written
+func (idx *Indexer) intercept(w http.ResponseWriter, r *http.Request) bool {
+	idx.mu.Lock()
+	idx.requests = append(idx.requests, Request{Path: r.URL.Path, Params: r.URL.Query()})
+	latency := idx.latency
+	var fault Fault
+	if len(idx.faults) > 0 {
+		fault = idx.faults[0]
+		idx.faults = idx.faults[1:]
+	}
+	idx.mu.Unlock()
+
+	if delay := latency + fault.Delay; delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+	switch {
+	case fault.Status != 0:
+		w.WriteHeader(fault.Status)
+		return true
+	case fault.APIError != 0:
+		server.WriteError(w, newznab.NewAPIError(fault.APIError)) // nolint:errcheck
+		return true
+	case fault.Malformed:
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel><item><title>`)) // nolint:errcheck
+		return true
+	}
+	return false
+}
+
+// DefaultCapabilities returns the capabilities of a typical indexer
+func DefaultCapabilities() newznab.Capabilities {
+	var caps newznab.Capabilities
+	caps.Server.Title = "newznabtest"
+	caps.Searching.Search.Available = "yes"
+	caps.Searching.Search.SupportedParams = "q"
+	caps.Searching.TvSearch.Available = "yes"
+	caps.Searching.TvSearch.SupportedParams = "q,rid,tvdbid,tvmazeid,season,ep"
+	caps.Searching.MovieSearch.Available = "yes"
+	caps.Searching.MovieSearch.SupportedParams = "q,imdbid,tmdbid"
+	caps.Categories.Category = newznab.DefaultCategories().CapsCategories()
+	return caps
+}
+
+// backend serves the seeded items of an Indexer
+type backend struct {
+	idx *Indexer
+}
+
+func (b backend) Capabilities(ctx context.Context) (newznab.Capabilities, error) {
+	b.idx.mu.Lock()
+	defer b.idx.mu.Unlock()
+	return b.idx.caps, nil
+}
+
+func (b backend) Search(ctx context.Context, q server.Query) (server.Results, error) {
+	b.idx.mu.Lock()
+	defer b.idx.mu.Unlock()
+
+	var matches []newznab.NZB
+	for _, nzb := range b.idx.nzbs {
+		if matchesQuery(nzb, q) {
+			matches = append(matches, nzb)
+		}
+	}
+	// Feeds are sorted newest first
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].PubDate.After(matches[j].PubDate)
+	})
+
+	res := server.Results{Total: len(matches)}
+	if q.Offset < len(matches) {
+		end := q.Offset + q.Limit
+		if end > len(matches) {
+			end = len(matches)
+		}
+		res.NZBs = matches[q.Offset:end]
+	}
+	return res, nil
+}
+
+func (b backend) Details(ctx context.Context, id string) (newznab.NZB, error) {
+	b.idx.mu.Lock()
+	defer b.idx.mu.Unlock()
+	for _, nzb := range b.idx.nzbs {
+		if nzb.ID == id {
+			nzb.NumComments = len(b.idx.comments[id])
+			return nzb, nil
+		}
+	}
+	return newznab.NZB{}, server.ErrNotFound
+}
+
+func (b backend) Download(ctx context.Context, id string) ([]byte, error) {
+	if _, err := b.Details(ctx, id); err != nil {
+		return nil, err
+	}
+	b.idx.mu.Lock()
+	defer b.idx.mu.Unlock()
+	if data, ok := b.idx.payloads[id]; ok {
+		return data, nil
+	}
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<nzb xmlns="http://www.newzbin.com/DTD/2003/nzb"><head><meta type="title">` + id + `</meta></head></nzb>`), nil
+}
+
+func (b backend) Comments(ctx context.Context, id string) ([]newznab.Comment, error) {
+	if _, err := b.Details(ctx, id); err != nil {
+		return nil, err
+	}
+	b.idx.mu.Lock()
+	defer b.idx.mu.Unlock()
+	return b.idx.comments[id], nil
+}
+
+// AddComment stores comments posted with t=commentadd, they're attributed to the user of the indexer
+func (b backend) AddComment(ctx context.Context, id string, text string) (newznab.Comment, error) {
+	if _, err := b.Details(ctx, id); err != nil {
+		return newznab.Comment{}, err
+	}
+	b.idx.mu.Lock()
+	defer b.idx.mu.Unlock()
+	comment := newznab.Comment{
+		ID:      id + "-comment-" + strconv.Itoa(len(b.idx.comments[id])+1),
+		Author:  "user-" + strconv.Itoa(b.idx.UserID),
+		Content: text,
+		PubDate: time.Now().UTC().Truncate(time.Second),
+	}
+	comment.Title = comment.Author
+	b.idx.comments[id] = append(b.idx.comments[id], comment)
+	return comment, nil
+}
+
+func (b backend) CartAdd(ctx context.Context, apikey string, id string) error {
+	if _, err := b.Details(ctx, id); err != nil {
+		return err
+	}
+	b.idx.mu.Lock()
+	defer b.idx.mu.Unlock()
+	for _, cartID := range b.idx.carts[apikey] {
+		if cartID == id {
+			return newznab.NewAPIError(newznab.ErrorItemAlreadyExists)
+		}
+	}
+	b.idx.carts[apikey] = append(b.idx.carts[apikey], id)
+	return nil
+}
+
+func (b backend) CartDelete(ctx context.Context, apikey string, id string) error {
+	b.idx.mu.Lock()
+	defer b.idx.mu.Unlock()
+	cart := b.idx.carts[apikey]
+	for i, cartID := range cart {
+		if cartID == id {
+			b.idx.carts[apikey] = append(cart[:i:i], cart[i+1:]...)
+			return nil
+		}
+	}
+	return server.ErrNotFound
+}
+
+func (b backend) Cart(ctx context.Context, apikey string, remove bool) ([]newznab.NZB, error) {
+	b.idx.mu.Lock()
+	ids := b.idx.carts[apikey]
+	if remove {
+		delete(b.idx.carts, apikey)
+	}
+	b.idx.mu.Unlock()
+	nzbs := make([]newznab.NZB, 0, len(ids))
+	for _, id := range ids {
+		nzb, err := b.Details(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		nzbs = append(nzbs, nzb)
+	}
+	return nzbs, nil
+}
+
+// User reports the requests and grabs made so far by all accounts
+func (b backend) User(ctx context.Context, apikey string) (newznab.UserInfo, error) {
+	b.idx.mu.Lock()
+	defer b.idx.mu.Unlock()
+	user := newznab.UserInfo{Username: "user-" + strconv.Itoa(b.idx.UserID), Role: "User"}
+	if account, ok := b.idx.accounts[apikey]; ok {
+		user.Username = account.Username
+	}
+	for _, req := range b.idx.requests {
+		switch req.Params.Get("t") {
+		case "get":
+			user.DownloadRequests++
+			user.Grabs++
+		default:
+			user.APIRequests++
+		}
+	}
+	return user, nil
+}
+
+// Register creates an account when the capabilities have open registration
+func (b backend) Register(ctx context.Context, email string) (newznab.Registration, error) {
+	b.idx.mu.Lock()
+	defer b.idx.mu.Unlock()
+	if !b.idx.caps.RegistrationOpen() {
+		return newznab.Registration{}, newznab.NewAPIError(newznab.ErrorRegistrationClosed)
+	}
+	for _, account := range b.idx.accounts {
+		if account.Username == email {
+			return newznab.Registration{}, newznab.NewAPIError(newznab.ErrorEmailTaken)
+		}
+	}
+	id := b.idx.UserID + len(b.idx.accounts) + 1
+	account := newznab.Registration{
+		Username: email,
+		Password: "password-" + strconv.Itoa(id),
+		APIKey:   "api-key-" + strconv.Itoa(id),
+		UserID:   id,
+	}
+	b.idx.accounts[account.APIKey] = account
+	return account, nil
+}
+
+func matchesQuery(nzb newznab.NZB, q server.Query) bool {
+	if q.Query != "" && !strings.Contains(strings.ToLower(nzb.Title), strings.ToLower(q.Query)) {
+		return false
+	}
+	if len(q.Categories) > 0 && !inCategories(nzb.Category, q.Categories) {
+		return false
+	}
+	switch q.Type {
+	case "tvsearch":
+		if !matchesID(nzb.TVRageID, q.TVRageID) || !matchesID(nzb.TVDBID, q.TVDBID) || !matchesID(nzb.TVMazeID, q.TVMazeID) {
+			return false
+		}
+		if !matchesNumber(nzb.Season, q.Season) || !matchesNumber(nzb.Episode, q.Episode) {
+			return false
+		}
+	case "movie":
+		if !matchesID(strings.TrimPrefix(nzb.IMDBID, "tt"), strings.TrimPrefix(q.IMDBID, "tt")) {
+			return false
+		}
+		if q.IDs.TMDB != 0 && nzb.MediaIDs.TMDB != q.IDs.TMDB {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesID(have string, want string) bool {
+	return want == "" || have == want
+}
+
+// matchesNumber compares season and episode values ignoring prefixes like "S" and "E" and leading zeros
+func matchesNumber(have string, want string) bool {
+	if want == "" {
+		return true
+	}
+	normalize := func(s string) string {
+		s = strings.TrimLeft(strings.ToUpper(s), "SE")
+		if trimmed := strings.TrimLeft(s, "0"); trimmed != "" {
+			return trimmed
+		}
+		return s
+	}
+	return normalize(have) == normalize(want)
+}
+
+func inCategories(nzbCategories []string, wanted []int) bool {
+	for _, category := range nzbCategories {
+		id, err := strconv.Atoi(category)
+		if err != nil {
+			continue
+		}
+		parent, hasParent := newznab.ParentOf(id)
+		for _, w := range wanted {
+			if id == w || (hasParent && parent.ID == w) {
+				return true
+			}
+		}
+	}
+	return false
+}
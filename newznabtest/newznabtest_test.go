@@ -0,0 +1,189 @@
+package newznabtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/stretchr/testify/require"
+)
+
+func seededIndexer() *Indexer {
+	idx := NewIndexer()
+	idx.AddNZBs(
+		newznab.NZB{
+			ID:       "show-1",
+			Title:    "Show.S01E02.720p.HDTV.x264-GRP",
+			Category: []string{"5040"},
+			TVDBID:   "1234",
+			Season:   "1",
+			Episode:  "2",
+			Size:     1000,
+			PubDate:  time.Date(2017, 5, 1, 0, 0, 0, 0, time.UTC),
+		},
+		newznab.NZB{
+			ID:       "show-2",
+			Title:    "Show.S01E03.720p.HDTV.x264-GRP",
+			Category: []string{"5040"},
+			TVDBID:   "1234",
+			Season:   "1",
+			Episode:  "3",
+			Size:     1000,
+			PubDate:  time.Date(2017, 5, 2, 0, 0, 0, 0, time.UTC),
+		},
+		newznab.NZB{
+			Title:    "Movie.2016.1080p.BluRay.x264-GRP",
+			Category: []string{"2040"},
+			IMDBID:   "0123456",
+			Size:     5000,
+			PubDate:  time.Date(2017, 5, 3, 0, 0, 0, 0, time.UTC),
+		},
+	)
+	return idx
+}
+
+func TestIndexer(t *testing.T) {
+	t.Run("search", func(t *testing.T) {
+		idx := seededIndexer()
+		defer idx.Close()
+		client := idx.Client()
+
+		results, err := client.SearchWithTVDB([]int{5000}, 1234, 1, 3)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, "show-2", results[0].ID)
+		idx.RequireLastQuery(t, map[string]string{"t": "tvsearch", "tvdbid": "1234", "season": "1", "ep": "3", "cat": "5000"})
+
+		results, err = client.SearchWithIMDB([]int{2000}, "0123456")
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, "nzb-3", results[0].ID)
+
+		results, err = client.SearchWithQuery(nil, "show", "search")
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		require.Equal(t, "show-2", results[0].ID, "newest first")
+
+		results, err = client.LoadRSSFeed([]int{5040}, 10)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		require.Equal(t, "/rss", idx.LastRequest().Path)
+		require.Len(t, idx.Requests(), 4)
+	})
+
+	t.Run("caps, comments and payloads", func(t *testing.T) {
+		idx := seededIndexer()
+		defer idx.Close()
+		client := idx.Client()
+
+		caps, err := client.Capabilities()
+		require.NoError(t, err)
+		require.True(t, caps.Supports("tvsearch", "tvdbid"))
+
+		caps.Searching.MovieSearch.Available = "no"
+		idx.SetCapabilities(caps)
+		caps, err = client.Capabilities()
+		require.NoError(t, err)
+		require.False(t, caps.Supports("movie", "imdbid"))
+
+		idx.AddComments("show-1", newznab.Comment{Title: "user", Content: "thanks", PubDate: time.Date(2017, 5, 4, 0, 0, 0, 0, time.UTC)})
+		nzb := newznab.NZB{ID: "show-1"}
+		require.NoError(t, client.PopulateComments(&nzb))
+		require.Len(t, nzb.Comments, 1)
+		require.Equal(t, "thanks", nzb.Comments[0].Content)
+
+		comment, err := client.AddComment(context.Background(), nzb, "great release")
+		require.NoError(t, err)
+		require.NotEmpty(t, comment.ID)
+		comments, err := client.Comments(context.Background(), nzb, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, comments, 1)
+		require.Equal(t, comment.ID, comments[0].ID)
+		require.Equal(t, "great release", comments[0].Content)
+		require.Equal(t, "user-1", comments[0].Author)
+
+		require.NoError(t, client.CartAdd(context.Background(), nzb))
+		require.Equal(t, []string{"show-1"}, idx.Cart(idx.APIKey))
+		require.Error(t, client.CartAdd(context.Background(), nzb), "already in the cart")
+		cart, err := client.LoadCartFeed(context.Background(), true)
+		require.NoError(t, err)
+		require.Len(t, cart, 1)
+		require.Empty(t, idx.Cart(idx.APIKey))
+		require.EqualError(t, client.CartDelete(context.Background(), nzb), "newznab api error 300: No such item")
+
+		user, err := client.UserInfo(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "user-1", user.Username)
+		require.NotZero(t, user.APIRequests)
+
+		_, err = client.Register(context.Background(), "someone@example.com")
+		require.EqualError(t, err, "newznab api error 104: Registrations are closed")
+		caps.Registration = &newznab.CapsRegistration{Available: "yes", Open: "yes"}
+		idx.SetCapabilities(caps)
+		reg, err := client.Register(context.Background(), "someone@example.com")
+		require.NoError(t, err)
+		user, err = newznab.New(idx.URL, reg.APIKey, reg.UserID, false).UserInfo(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "someone@example.com", user.Username)
+
+		idx.SetPayload("show-1", []byte("<nzb>payload</nzb>"))
+		data, err := client.DownloadNZB(nzb)
+		require.NoError(t, err)
+		require.Equal(t, "<nzb>payload</nzb>", string(data))
+
+		data, err = client.DownloadNZB(newznab.NZB{ID: "show-2"})
+		require.NoError(t, err)
+		require.Contains(t, string(data), "show-2")
+	})
+
+	t.Run("faults", func(t *testing.T) {
+		idx := seededIndexer()
+		defer idx.Close()
+		client := idx.Client()
+
+		idx.FailNext(Fault{APIError: newznab.ErrorIncorrectCredentials}, Fault{Status: 429}, Fault{Status: 500}, Fault{Malformed: true})
+
+		_, err := client.SearchWithQuery(nil, "show", "search")
+		require.Error(t, err)
+		apiErr, ok := err.(*newznab.APIError)
+		require.True(t, ok)
+		require.Equal(t, newznab.ErrorIncorrectCredentials, apiErr.Code)
+
+		for i := 0; i < 2; i++ {
+			_, err = client.SearchWithQuery(nil, "show", "search")
+			require.Error(t, err)
+		}
+
+		page, err := client.Search(context.Background(), newznab.SearchRequest{Query: "show"})
+		require.NoError(t, err, "a truncated feed keeps the items decoded before the break")
+		require.Empty(t, page.NZBs)
+		require.Len(t, page.Warnings, 1)
+
+		results, err := client.SearchWithQuery(nil, "show", "search")
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		require.Len(t, idx.Requests(), 5)
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		idx := seededIndexer()
+		defer idx.Close()
+
+		idx.FailNext(Fault{Delay: 50 * time.Millisecond})
+		start := time.Now()
+		_, err := idx.Client().SearchWithQuery(nil, "show", "search")
+		require.NoError(t, err)
+		require.True(t, time.Since(start) >= 50*time.Millisecond)
+	})
+
+	t.Run("wrong api key", func(t *testing.T) {
+		idx := seededIndexer()
+		defer idx.Close()
+
+		client := newznab.New(idx.URL, "wrong", idx.UserID, false)
+		_, err := client.SearchWithQuery(nil, "show", "search")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "100")
+	})
+}
@@ -0,0 +1,279 @@
+package newznabtest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/pkg/errors"
+)
+
+// Mode selects whether a Recorder captures or replays exchanges
+type Mode int
+
+// Recorder modes
+const (
+	// ModeReplay serves recorded exchanges and fails requests that weren't recorded
+	ModeReplay Mode = iota
+	// ModeRecord sends requests to the real indexer and saves the exchanges
+	ModeRecord
+)
+
+// Redacted replaces secrets in recorded exchanges
+const Redacted = "REDACTED"
+
+// DefaultRedactedParams are the query parameters carrying credentials in newznab requests
+var DefaultRedactedParams = []string{"apikey", "r", "i"}
+
+// DefaultRedactedHeaders are the response headers whose values are replaced entirely
+var DefaultRedactedHeaders = []string{"Set-Cookie", "Authorization", "Proxy-Authenticate", "WWW-Authenticate"}
+
+// Interaction is a single recorded HTTP exchange
+type Interaction struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+	// BodyBase64 holds the body instead of Body when it isn't valid UTF-8
+	BodyBase64 string `json:"body_base64,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that records exchanges to a JSON file and replays them.
+// Plug it into a client with Wrap.
+type Recorder struct {
+	// Transport sends requests in record mode, defaults to http.DefaultTransport
+	Transport http.RoundTripper
+	// RedactedParams are removed from recorded queries and their values from recorded paths, headers and bodies,
+	// they are also ignored when matching requests. Defaults to DefaultRedactedParams.
+	RedactedParams []string
+	// RedactedHeaders are response headers recorded as Redacted. Defaults to DefaultRedactedHeaders.
+	RedactedHeaders []string
+
+	path string
+	mode Mode
+
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     map[int]bool
+}
+
+// NewRecorder returns a Recorder backed by the given file.
+// In replay mode the file is loaded right away, in record mode it is overwritten by the first exchange.
+func NewRecorder(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{
+		path:     path,
+		mode:     mode,
+		replayed: map[int]bool{},
+	}
+	if mode == ModeRecord {
+		return r, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read recording")
+	}
+	if err := json.Unmarshal(data, &r.interactions); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal recording")
+	}
+	return r, nil
+}
+
+// Interactions returns the exchanges recorded or loaded so far
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Wrap returns a copy of the client that sends its requests through this recorder.
+// In record mode the requests go on through the transport of the client, so settings like
+// skipping certificate verification are kept, unless Transport is set.
+func (r *Recorder) Wrap(c newznab.Client) newznab.Client {
+	if r.Transport == nil {
+		r.Transport = c.Transport()
+	}
+	return c.WithTransport(r)
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == ModeRecord {
+		return r.record(req)
+	}
+	return r.replay(req)
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response body")
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	secrets := r.secrets(req.URL.Query())
+	recorded := redact(body, secrets)
+	interaction := Interaction{
+		Method: req.Method,
+		Path:   string(redact([]byte(req.URL.Path), secrets)),
+		Query:  r.normalizeQuery(req.URL.Query()),
+		Status: res.StatusCode,
+		Header: r.redactHeader(res.Header, secrets),
+	}
+	if utf8.Valid(recorded) {
+		interaction.Body = string(recorded)
+	} else {
+		interaction.BodyBase64 = base64.StdEncoding.EncodeToString(recorded)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, interaction)
+	return res, r.save()
+}
+
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	query := r.normalizeQuery(req.URL.Query())
+	path := string(redact([]byte(req.URL.Path), r.secrets(req.URL.Query())))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Identical requests are served in recorded order, the last one repeats once all were used
+	match := -1
+	for i, interaction := range r.interactions {
+		if interaction.Method != req.Method || interaction.Path != path || interaction.Query != query {
+			continue
+		}
+		match = i
+		if !r.replayed[i] {
+			break
+		}
+	}
+	if match < 0 {
+		return nil, errors.Errorf("no recorded interaction for %s %s?%s", req.Method, path, query)
+	}
+	r.replayed[match] = true
+
+	interaction := r.interactions[match]
+	body := []byte(interaction.Body)
+	if interaction.BodyBase64 != "" {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(interaction.BodyBase64); err != nil {
+			return nil, errors.Wrap(err, "failed to decode recorded body")
+		}
+	}
+	header := interaction.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        http.StatusText(interaction.Status),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal recording")
+	}
+	return errors.Wrap(ioutil.WriteFile(r.path, data, os.FileMode(0644)), "failed to write recording")
+}
+
+func (r *Recorder) redactedParams() []string {
+	if r.RedactedParams == nil {
+		return DefaultRedactedParams
+	}
+	return r.RedactedParams
+}
+
+// normalizeQuery drops redacted params and sorts the rest so parameter order doesn't matter
+func (r *Recorder) normalizeQuery(query url.Values) string {
+	normalized := url.Values{}
+	for key, values := range query {
+		normalized[key] = append([]string(nil), values...)
+		sort.Strings(normalized[key])
+	}
+	for _, param := range r.redactedParams() {
+		delete(normalized, param)
+	}
+	return normalized.Encode()
+}
+
+func (r *Recorder) redactedHeaders() []string {
+	if r.RedactedHeaders == nil {
+		return DefaultRedactedHeaders
+	}
+	return r.RedactedHeaders
+}
+
+// secrets returns the values of the redacted params of a request.
+// Short values like user ids are left out as they would mangle unrelated content.
+func (r *Recorder) secrets(query url.Values) []string {
+	var secrets []string
+	for _, param := range r.redactedParams() {
+		for _, value := range query[param] {
+			if len(value) < 8 {
+				continue
+			}
+			secrets = append(secrets, value)
+			if escaped := url.QueryEscape(value); escaped != value {
+				secrets = append(secrets, escaped)
+			}
+		}
+	}
+	return secrets
+}
+
+// redactHeader copies a response header, replacing redacted headers and secrets in the others,
+// like an api key in the Location of a redirect
+func (r *Recorder) redactHeader(header http.Header, secrets []string) http.Header {
+	redacted := http.Header{}
+	for key, values := range header {
+		for _, value := range values {
+			redacted.Add(key, string(redact([]byte(value), secrets)))
+		}
+	}
+	for _, key := range r.redactedHeaders() {
+		if values := redacted.Values(key); len(values) > 0 {
+			redacted[http.CanonicalHeaderKey(key)] = []string{Redacted}
+		}
+	}
+	if len(redacted) == 0 {
+		return nil
+	}
+	return redacted
+}
+
+// redact replaces the given secrets, like api keys in download links, in a path, header or body
+func redact(data []byte, secrets []string) []byte {
+	for _, secret := range secrets {
+		data = bytes.Replace(data, []byte(secret), []byte(Redacted), -1)
+	}
+	return data
+}
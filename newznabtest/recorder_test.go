@@ -0,0 +1,110 @@
+package newznabtest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.json")
+	idx := seededIndexer()
+
+	t.Run("record", func(t *testing.T) {
+		recorder, err := NewRecorder(path, ModeRecord)
+		require.NoError(t, err)
+		client := recorder.Wrap(idx.Client())
+
+		results, err := client.SearchWithTVDB([]int{5000}, 1234, 1, 3)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		_, err = client.LoadRSSFeed([]int{5040}, 10)
+		require.NoError(t, err)
+		require.Len(t, recorder.Interactions(), 2)
+
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		require.NotContains(t, string(data), DefaultAPIKey, "api keys are redacted")
+		require.Contains(t, string(data), Redacted)
+	})
+	idx.Close()
+
+	t.Run("replay", func(t *testing.T) {
+		recorder, err := NewRecorder(path, ModeReplay)
+		require.NoError(t, err)
+		client := recorder.Wrap(newznab.New(idx.URL, "another-key", 2, false))
+
+		results, err := client.SearchWithTVDB([]int{5000}, 1234, 1, 3)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, "show-2", results[0].ID)
+
+		results, err = client.LoadRSSFeed([]int{5040}, 10)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+
+		_, err = client.SearchWithTVDB([]int{5000}, 1234, 1, 4)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "no recorded interaction")
+	})
+
+	t.Run("parameter order is ignored", func(t *testing.T) {
+		recorder, err := NewRecorder(path, ModeReplay)
+		require.NoError(t, err)
+		httpClient := &http.Client{Transport: recorder}
+
+		res, err := httpClient.Get(idx.URL + "/api?ep=3&t=tvsearch&season=1&cat=5000&tvdbid=1234&apikey=x")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("secrets in paths and headers", func(t *testing.T) {
+		const key = "0123456789abcdef"
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret-session"})
+			w.Header().Set("Location", "https://example.com/getnzb/1.nzb?apikey="+key)
+			w.Header().Set("Content-Type", "application/x-nzb")
+			w.Write([]byte("nzb")) // nolint:errcheck
+		}))
+		defer ts.Close()
+		secretPath := filepath.Join(t.TempDir(), "secrets.json")
+
+		recorder, err := NewRecorder(secretPath, ModeRecord)
+		require.NoError(t, err)
+		recorder.Wrap(newznab.New(ts.URL, key, 1234, true))
+		res, err := (&http.Client{Transport: recorder}).Get(ts.URL + "/getnzb/" + key + "/1.nzb?apikey=" + key)
+		require.NoError(t, err, "the recorder keeps the insecure transport of the client")
+		res.Body.Close()
+
+		recording, err := ioutil.ReadFile(secretPath)
+		require.NoError(t, err)
+		require.NotContains(t, string(recording), key)
+		require.NotContains(t, string(recording), "secret-session")
+		interaction := recorder.Interactions()[0]
+		require.Equal(t, "/getnzb/"+Redacted+"/1.nzb", interaction.Path)
+		require.Equal(t, []string{Redacted}, interaction.Header["Set-Cookie"])
+		require.Equal(t, "https://example.com/getnzb/1.nzb?apikey="+Redacted, interaction.Header.Get("Location"))
+		require.Equal(t, "application/x-nzb", interaction.Header.Get("Content-Type"))
+
+		replayer, err := NewRecorder(secretPath, ModeReplay)
+		require.NoError(t, err)
+		const otherKey = "fedcba9876543210"
+		res, err = (&http.Client{Transport: replayer}).Get(ts.URL + "/getnzb/" + otherKey + "/1.nzb?apikey=" + otherKey)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, "nzb", string(body))
+	})
+
+	t.Run("missing recording", func(t *testing.T) {
+		_, err := NewRecorder(filepath.Join(t.TempDir(), "missing.json"), ModeReplay)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,108 @@
+package proxy
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/pkg/errors"
+)
+
+// Jackett meta-indexers, filters like "tag:hd" or "type:private" can be used as ids as well
+const (
+	JackettAll = "all"
+)
+
+// Jackett talks to a Jackett server
+type Jackett struct {
+	baseURL  string
+	apikey   string
+	insecure bool
+	client   *http.Client
+}
+
+// NewJackett returns a new Jackett for the given server URL and API key
+func NewJackett(baseURL string, apikey string, insecure bool) Jackett {
+	return Jackett{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		apikey:   apikey,
+		insecure: insecure,
+		client:   httpClient(insecure),
+	}
+}
+
+// TorznabPath returns the torznab api path of the indexer with the given id
+func (j Jackett) TorznabPath(id string) string {
+	return "/api/v2.0/indexers/" + url.PathEscape(id) + "/results/torznab/api"
+}
+
+// Client returns a torznab client for the indexer with the given id, or for a meta-indexer like JackettAll
+func (j Jackett) Client(id string) newznab.Client {
+	return newznab.New(j.baseURL, j.apikey, 0, j.insecure).WithAPIPath(j.TorznabPath(id))
+}
+
+// All returns a client searching every configured indexer at once
+func (j Jackett) All() newznab.Client {
+	return j.Client(JackettAll)
+}
+
+type jackettIndexers struct {
+	Indexers []struct {
+		ID           string               `xml:"id,attr"`
+		Configured   bool                 `xml:"configured,attr"`
+		Title        string               `xml:"title"`
+		Description  string               `xml:"description"`
+		Link         string               `xml:"link"`
+		Language     string               `xml:"language"`
+		Type         string               `xml:"type"`
+		Capabilities newznab.Capabilities `xml:"caps"`
+	} `xml:"indexer"`
+	ErrorCode int    `xml:"code,attr"`
+	ErrorDesc string `xml:"description,attr"`
+}
+
+// Indexers lists every indexer known to Jackett using the t=indexers endpoint of the "all" meta-indexer
+func (j Jackett) Indexers(ctx context.Context) ([]Indexer, error) {
+	return j.indexers(ctx, url.Values{})
+}
+
+// ConfiguredIndexers lists only the indexers that have been set up in Jackett
+func (j Jackett) ConfiguredIndexers(ctx context.Context) ([]Indexer, error) {
+	return j.indexers(ctx, url.Values{"configured": []string{"true"}})
+}
+
+func (j Jackett) indexers(ctx context.Context, vals url.Values) ([]Indexer, error) {
+	vals.Set("t", "indexers")
+	vals.Set("apikey", j.apikey)
+	data, err := get(ctx, j.client, j.baseURL+j.TorznabPath(JackettAll)+"?"+vals.Encode(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list jackett indexers")
+	}
+	var resp jackettIndexers
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal jackett indexers")
+	}
+	if resp.ErrorCode != 0 {
+		return nil, &newznab.APIError{Code: resp.ErrorCode, Description: resp.ErrorDesc}
+	}
+
+	indexers := make([]Indexer, 0, len(resp.Indexers))
+	for _, raw := range resp.Indexers {
+		caps := raw.Capabilities
+		indexers = append(indexers, Indexer{
+			ID:           raw.ID,
+			Name:         raw.Title,
+			Description:  raw.Description,
+			Link:         raw.Link,
+			Language:     raw.Language,
+			Type:         raw.Type,
+			Protocol:     ProtocolTorrent,
+			Configured:   raw.Configured,
+			Capabilities: &caps,
+		})
+	}
+	return indexers, nil
+}
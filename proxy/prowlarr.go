@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/pkg/errors"
+)
+
+// Prowlarr talks to a Prowlarr server
+type Prowlarr struct {
+	baseURL  string
+	apikey   string
+	insecure bool
+	client   *http.Client
+}
+
+// NewProwlarr returns a new Prowlarr for the given server URL and API key
+func NewProwlarr(baseURL string, apikey string, insecure bool) Prowlarr {
+	return Prowlarr{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		apikey:   apikey,
+		insecure: insecure,
+		client:   httpClient(insecure),
+	}
+}
+
+// APIPath returns the newznab or torznab api path of the indexer with the given id
+func (p Prowlarr) APIPath(id string) string {
+	return "/" + url.PathEscape(id) + "/api"
+}
+
+// Client returns a newznab or torznab client for the indexer with the given id
+func (p Prowlarr) Client(id string) newznab.Client {
+	return newznab.New(p.baseURL, p.apikey, 0, p.insecure).WithAPIPath(p.APIPath(id))
+}
+
+type prowlarrCategory struct {
+	ID            int                `json:"id"`
+	Name          string             `json:"name"`
+	SubCategories []prowlarrCategory `json:"subCategories"`
+}
+
+type prowlarrIndexer struct {
+	ID           int      `json:"id"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Language     string   `json:"language"`
+	Privacy      string   `json:"privacy"`
+	Protocol     string   `json:"protocol"`
+	Enable       bool     `json:"enable"`
+	IndexerURLs  []string `json:"indexerUrls"`
+	Capabilities struct {
+		Categories        []prowlarrCategory `json:"categories"`
+		SearchParams      []string           `json:"searchParams"`
+		TVSearchParams    []string           `json:"tvSearchParams"`
+		MovieSearchParams []string           `json:"movieSearchParams"`
+	} `json:"capabilities"`
+}
+
+// Indexers lists every indexer added to Prowlarr, disabled indexers are not Configured
+func (p Prowlarr) Indexers(ctx context.Context) ([]Indexer, error) {
+	data, err := get(ctx, p.client, p.baseURL+"/api/v1/indexer", http.Header{"X-Api-Key": []string{p.apikey}})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list prowlarr indexers")
+	}
+	var raw []prowlarrIndexer
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal prowlarr indexers")
+	}
+
+	indexers := make([]Indexer, 0, len(raw))
+	for _, r := range raw {
+		indexer := Indexer{
+			ID:           strconv.Itoa(r.ID),
+			Name:         r.Name,
+			Description:  r.Description,
+			Language:     r.Language,
+			Type:         prowlarrPrivacy(r.Privacy),
+			Protocol:     r.Protocol,
+			Configured:   r.Enable,
+			Capabilities: r.capabilities(),
+		}
+		if len(r.IndexerURLs) > 0 {
+			indexer.Link = r.IndexerURLs[0]
+		}
+		indexers = append(indexers, indexer)
+	}
+	return indexers, nil
+}
+
+// capabilities converts the capabilities reported by Prowlarr to their newznab form
+func (r prowlarrIndexer) capabilities() *newznab.Capabilities {
+	var caps newznab.Capabilities
+	caps.Server.Title = r.Name
+	caps.Searching.Search.Available, caps.Searching.Search.SupportedParams = prowlarrParams(r.Capabilities.SearchParams)
+	caps.Searching.TvSearch.Available, caps.Searching.TvSearch.SupportedParams = prowlarrParams(r.Capabilities.TVSearchParams)
+	caps.Searching.MovieSearch.Available, caps.Searching.MovieSearch.SupportedParams = prowlarrParams(r.Capabilities.MovieSearchParams)
+	for _, category := range r.Capabilities.Categories {
+		capsCategory := newznab.CapsCategory{ID: strconv.Itoa(category.ID), Name: category.Name}
+		for _, sub := range category.SubCategories {
+			capsCategory.Subcat = append(capsCategory.Subcat, newznab.CapsSubcat{ID: strconv.Itoa(sub.ID), Name: sub.Name})
+		}
+		caps.Categories.Category = append(caps.Categories.Category, capsCategory)
+	}
+	return &caps
+}
+
+// prowlarrParams maps Prowlarr parameter names like "tvdbId" to their newznab names
+func prowlarrParams(params []string) (string, string) {
+	if len(params) == 0 {
+		return "no", ""
+	}
+	names := make([]string, 0, len(params))
+	for _, param := range params {
+		names = append(names, strings.ToLower(param))
+	}
+	return "yes", strings.Join(names, ",")
+}
+
+// prowlarrPrivacy maps Prowlarr privacy values to the Jackett indexer types
+func prowlarrPrivacy(privacy string) string {
+	if privacy == "semiPrivate" {
+		return "semi-private"
+	}
+	return privacy
+}
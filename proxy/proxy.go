@@ -0,0 +1,107 @@
+// Package proxy lists the indexers behind Jackett and Prowlarr and builds a client for each of them.
+package proxy
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/pkg/errors"
+)
+
+// Indexer protocols
+const (
+	ProtocolTorrent = "torrent"
+	ProtocolUsenet  = "usenet"
+)
+
+// Indexer is an indexer exposed by a proxy
+type Indexer struct {
+	ID          string
+	Name        string
+	Description string
+	Link        string
+	Language    string
+	// Type is "public", "semi-private" or "private"
+	Type     string
+	Protocol string
+	// Configured is false for indexers the proxy knows about but that haven't been set up or are disabled
+	Configured   bool
+	Capabilities *newznab.Capabilities
+}
+
+// Proxy is a service like Jackett or Prowlarr that exposes many indexers through newznab compatible endpoints
+type Proxy interface {
+	// Indexers lists every indexer known to the proxy
+	Indexers(ctx context.Context) ([]Indexer, error)
+	// Client returns a client for the indexer with the given id
+	Client(id string) newznab.Client
+}
+
+// Configured returns only the configured indexers
+func Configured(indexers []Indexer) []Indexer {
+	var configured []Indexer
+	for _, indexer := range indexers {
+		if indexer.Configured {
+			configured = append(configured, indexer)
+		}
+	}
+	return configured
+}
+
+// Aggregator returns an aggregator searching every configured indexer of the proxy.
+// Capabilities reported by the proxy are used so no caps requests are needed.
+func Aggregator(ctx context.Context, p Proxy, timeout time.Duration) (*newznab.Aggregator, error) {
+	indexers, err := p.Indexers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var aggregated []newznab.Indexer
+	for _, indexer := range Configured(indexers) {
+		aggregated = append(aggregated, newznab.Indexer{
+			Name:         indexer.Name,
+			Client:       p.Client(indexer.ID),
+			Capabilities: indexer.Capabilities,
+		})
+	}
+	return newznab.NewAggregator(timeout, aggregated...), nil
+}
+
+// httpClient returns an HTTP client with the transport newznab.New sets up for the insecure flag
+func httpClient(insecure bool) *http.Client {
+	return &http.Client{Transport: newznab.New("", "", 0, insecure).Transport()}
+}
+
+// get fetches the given URL and fails on non 2xx responses
+func get(ctx context.Context, client *http.Client, url string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "http request failed: %s", stripAPIKey(url))
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response body")
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, errors.Errorf("unexpected status %d from %s", res.StatusCode, stripAPIKey(url))
+	}
+	return data, nil
+}
+
+func stripAPIKey(url string) string {
+	if i := strings.Index(url, "?"); i >= 0 {
+		return url[:i]
+	}
+	return url
+}
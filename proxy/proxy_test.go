@@ -0,0 +1,201 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/stretchr/testify/require"
+)
+
+const feed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:torznab="http://torznab.com/schemas/2015/feed">
+<channel>
+<item>
+<title>Show.S01E02.720p.HDTV.x264-GRP</title>
+<torznab:attr name="guid" value="abc"/>
+<torznab:attr name="seeders" value="10"/>
+</item>
+</channel>
+</rss>`
+
+const jackettIndexersXML = `<?xml version="1.0" encoding="UTF-8"?>
+<indexers>
+  <indexer id="1337x" configured="true">
+    <title>1337x</title>
+    <description>1337X is a Public torrent site</description>
+    <link>https://1337x.to/</link>
+    <language>en-US</language>
+    <type>public</type>
+    <caps>
+      <server title="Jackett"/>
+      <searching>
+        <search available="yes" supportedParams="q"/>
+        <tv-search available="yes" supportedParams="q,season,ep"/>
+        <movie-search available="yes" supportedParams="q"/>
+      </searching>
+      <categories>
+        <category id="5000" name="TV"><subcat id="5040" name="TV/HD"/></category>
+      </categories>
+    </caps>
+  </indexer>
+  <indexer id="rarbg" configured="false">
+    <title>RARBG</title>
+    <type>public</type>
+    <caps><server title="Jackett"/></caps>
+  </indexer>
+</indexers>`
+
+const prowlarrIndexersJSON = `[
+  {
+    "id": 1,
+    "name": "NZBgeek",
+    "description": "NZBgeek is a usenet indexer",
+    "language": "en-US",
+    "privacy": "private",
+    "protocol": "usenet",
+    "enable": true,
+    "indexerUrls": ["https://api.nzbgeek.info/"],
+    "capabilities": {
+      "categories": [{"id": 5000, "name": "TV", "subCategories": [{"id": 5040, "name": "TV/HD", "subCategories": []}]}],
+      "searchParams": ["q"],
+      "tvSearchParams": ["q", "season", "ep", "tvdbId", "rId", "tvMazeId"],
+      "movieSearchParams": ["q", "imdbId"]
+    }
+  },
+  {
+    "id": 2,
+    "name": "Disabled",
+    "privacy": "semiPrivate",
+    "protocol": "torrent",
+    "enable": false,
+    "capabilities": {}
+  }
+]`
+
+func TestJackett(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Query().Get("apikey") != "jackett-key" {
+			w.Write([]byte(`<error code="100" description="Invalid API Key"/>`)) // nolint:errcheck
+			return
+		}
+		if r.URL.Query().Get("t") == "indexers" {
+			require.Equal(t, "/api/v2.0/indexers/all/results/torznab/api", r.URL.Path)
+			w.Write([]byte(jackettIndexersXML)) // nolint:errcheck
+			return
+		}
+		w.Write([]byte(feed)) // nolint:errcheck
+	}))
+	defer ts.Close()
+	jackett := NewJackett(ts.URL+"/", "jackett-key", false)
+
+	t.Run("indexers", func(t *testing.T) {
+		indexers, err := jackett.Indexers(context.Background())
+		require.NoError(t, err)
+		require.Len(t, indexers, 2)
+		require.Equal(t, "1337x", indexers[0].ID)
+		require.Equal(t, "1337x", indexers[0].Name)
+		require.Equal(t, "public", indexers[0].Type)
+		require.Equal(t, ProtocolTorrent, indexers[0].Protocol)
+		require.True(t, indexers[0].Configured)
+		require.True(t, indexers[0].Capabilities.Supports("tvsearch", "season"))
+		require.Equal(t, "TV/HD", indexers[0].Capabilities.Categories.Category[0].Subcat[0].Name)
+		require.False(t, indexers[1].Configured)
+		require.Len(t, Configured(indexers), 1)
+	})
+
+	t.Run("clients", func(t *testing.T) {
+		results, err := jackett.Client("1337x").SearchWithQuery(nil, "show", "search")
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.True(t, results[0].IsTorrent)
+		require.Equal(t, "/api/v2.0/indexers/1337x/results/torznab/api", paths[len(paths)-1])
+
+		_, err = jackett.All().SearchWithQuery(nil, "show", "search")
+		require.NoError(t, err)
+		require.Equal(t, "/api/v2.0/indexers/all/results/torznab/api", paths[len(paths)-1])
+	})
+
+	t.Run("aggregator", func(t *testing.T) {
+		agg, err := Aggregator(context.Background(), jackett, time.Second)
+		require.NoError(t, err)
+		require.Len(t, agg.Indexers(), 1)
+		res := agg.SearchWithTVDB(context.Background(), nil, 1234, 1, 2)
+		require.Len(t, res.Reports, 1)
+		require.Equal(t, newznab.StatusSkipped, res.Reports[0].Status, "tvdbid isn't supported")
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := NewJackett(ts.URL, "wrong", false).Indexers(context.Background())
+		require.EqualError(t, err, "newznab api error 100: Invalid API Key")
+	})
+}
+
+func TestProwlarr(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/api/v1/indexer" {
+			if r.Header.Get("X-Api-Key") != "prowlarr-key" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(prowlarrIndexersJSON)) // nolint:errcheck
+			return
+		}
+		w.Write([]byte(feed)) // nolint:errcheck
+	}))
+	defer ts.Close()
+	prowlarr := NewProwlarr(ts.URL, "prowlarr-key", false)
+
+	t.Run("indexers", func(t *testing.T) {
+		indexers, err := prowlarr.Indexers(context.Background())
+		require.NoError(t, err)
+		require.Len(t, indexers, 2)
+
+		geek := indexers[0]
+		require.Equal(t, "1", geek.ID)
+		require.Equal(t, "NZBgeek", geek.Name)
+		require.Equal(t, ProtocolUsenet, geek.Protocol)
+		require.Equal(t, "private", geek.Type)
+		require.Equal(t, "https://api.nzbgeek.info/", geek.Link)
+		require.True(t, geek.Configured)
+		require.True(t, geek.Capabilities.Supports("tvsearch", "tvdbid"))
+		require.True(t, geek.Capabilities.Supports("tvsearch", "rid"))
+		require.True(t, geek.Capabilities.Supports("movie", "imdbid"))
+		require.Equal(t, "5040", geek.Capabilities.Categories.Category[0].Subcat[0].ID)
+
+		require.Equal(t, "semi-private", indexers[1].Type)
+		require.False(t, indexers[1].Configured)
+		require.False(t, indexers[1].Capabilities.Supports("search", "q"))
+	})
+
+	t.Run("client", func(t *testing.T) {
+		results, err := prowlarr.Client("1").SearchWithQuery(nil, "show", "search")
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.Equal(t, "/1/api", paths[len(paths)-1])
+		require.Equal(t, "/a%2Fb%3F/api", prowlarr.APIPath("a/b?"))
+	})
+
+	t.Run("insecure", func(t *testing.T) {
+		tls := httptest.NewTLSServer(ts.Config.Handler)
+		defer tls.Close()
+		_, err := NewProwlarr(tls.URL, "prowlarr-key", false).Indexers(context.Background())
+		require.Error(t, err)
+		indexers, err := NewProwlarr(tls.URL, "prowlarr-key", true).Indexers(context.Background())
+		require.NoError(t, err)
+		require.Len(t, indexers, 2)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := NewProwlarr(ts.URL, "wrong", false).Indexers(context.Background())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "401")
+	})
+}
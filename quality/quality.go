@@ -0,0 +1,258 @@
+// Package quality filters and ranks search results against a quality profile.
+package quality
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/mrobinsn/go-newznab/release"
+	"github.com/pkg/errors"
+)
+
+// Profile describes which releases are acceptable and which ones are preferred
+type Profile struct {
+	// Resolutions lists the allowed resolutions such as "1080p", empty allows all
+	Resolutions []string `json:"resolutions,omitempty"`
+	// PreferredResolutions lists resolutions from most to least preferred
+	PreferredResolutions []string `json:"preferred_resolutions,omitempty"`
+	// Sources lists the allowed sources such as "BluRay" or "WEB-DL", empty allows all
+	Sources []string `json:"sources,omitempty"`
+	// PreferredSources lists sources from most to least preferred
+	PreferredSources []string `json:"preferred_sources,omitempty"`
+	// Categories lists the allowed categories, empty allows all
+	Categories []int `json:"categories,omitempty"`
+
+	// Runtime is the runtime of the wanted movie or episode, required for the size limits
+	Runtime time.Duration `json:"runtime,omitempty"`
+	// MinSizePerMinute and MaxSizePerMinute limit the size in bytes per minute of runtime, zero disables the limit
+	MinSizePerMinute int64 `json:"min_size_per_minute,omitempty"`
+	MaxSizePerMinute int64 `json:"max_size_per_minute,omitempty"`
+
+	// Required terms must all appear in the title, Ignored terms must not.
+	// A term is a case-insensitive word, or a regular expression when wrapped in slashes like /x26[45]/
+	Required []string `json:"required,omitempty"`
+	Ignored  []string `json:"ignored,omitempty"`
+
+	// PreferredGroups lists release groups that get a bonus
+	PreferredGroups []string `json:"preferred_groups,omitempty"`
+
+	// MinSeeders rejects torrents with fewer seeders
+	MinSeeders int `json:"min_seeders,omitempty"`
+	// PreferFreeleech gives freeleech torrents a bonus
+	PreferFreeleech bool `json:"prefer_freeleech,omitempty"`
+}
+
+// Scores awarded for preferred properties
+const (
+	ResolutionScore = 100
+	SourceScore     = 50
+	GroupScore      = 30
+	FreeleechScore  = 25
+	RevisionScore   = 5
+	// Popularity adds up to this much for grabs and seeders
+	MaxPopularityScore = 20
+)
+
+// Result is a ranked search result
+type Result struct {
+	NZB     newznab.NZB     `json:"nzb"`
+	Release release.Release `json:"release"`
+	Score   int             `json:"score"`
+	// Rejected results do not satisfy the profile
+	Rejected bool `json:"rejected,omitempty"`
+	// Reasons explains the score and why the result was rejected
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+func (r *Result) explain(format string, args ...interface{}) {
+	r.Reasons = append(r.Reasons, fmt.Sprintf(format, args...))
+}
+
+func (r *Result) reject(format string, args ...interface{}) {
+	r.Rejected = true
+	r.explain("rejected: "+format, args...)
+}
+
+func (r *Result) add(score int, format string, args ...interface{}) {
+	r.Score += score
+	r.explain(fmt.Sprintf("%+d ", score)+format, args...)
+}
+
+// Rank scores the given NZBs against the profile.
+// Accepted results come first ordered by descending score, followed by the rejected ones.
+func Rank(nzbs []newznab.NZB, p Profile) ([]Result, error) {
+	required, err := compileTerms(p.Required)
+	if err != nil {
+		return nil, err
+	}
+	ignored, err := compileTerms(p.Ignored)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(nzbs))
+	for _, nzb := range nzbs {
+		res := Result{
+			NZB:     nzb,
+			Release: release.Parse(nzb.Title),
+		}
+		p.score(&res, required, ignored)
+		results = append(results, res)
+	}
+
+	sort.SliceStable(results, func(a, b int) bool {
+		if results[a].Rejected != results[b].Rejected {
+			return !results[a].Rejected
+		}
+		return results[a].Score > results[b].Score
+	})
+	return results, nil
+}
+
+// Accepted returns the results that were not rejected
+func Accepted(results []Result) []Result {
+	var accepted []Result
+	for _, res := range results {
+		if !res.Rejected {
+			accepted = append(accepted, res)
+		}
+	}
+	return accepted
+}
+
+func (p Profile) score(res *Result, required []*regexp.Regexp, ignored []*regexp.Regexp) {
+	nzb := res.NZB
+	resolution := NormalizeResolution(nzb.Resolution)
+	if resolution == "" {
+		resolution = res.Release.Resolution
+	}
+	source := res.Release.Source
+
+	if len(p.Resolutions) > 0 && indexOf(p.Resolutions, resolution) < 0 {
+		res.reject("resolution %q is not allowed", resolution)
+	}
+	if len(p.Sources) > 0 && indexOf(p.Sources, source) < 0 {
+		res.reject("source %q is not allowed", source)
+	}
+	if len(p.Categories) > 0 && !inCategories(nzb.Category, p.Categories) {
+		res.reject("categories %v are not allowed", nzb.Category)
+	}
+	if minutes := int64(p.Runtime / time.Minute); minutes > 0 && nzb.Size > 0 {
+		perMinute := nzb.Size / minutes
+		if p.MinSizePerMinute > 0 && perMinute < p.MinSizePerMinute {
+			res.reject("size of %d bytes per minute is below %d", perMinute, p.MinSizePerMinute)
+		}
+		if p.MaxSizePerMinute > 0 && perMinute > p.MaxSizePerMinute {
+			res.reject("size of %d bytes per minute is above %d", perMinute, p.MaxSizePerMinute)
+		}
+	}
+	for i, re := range required {
+		if !re.MatchString(nzb.Title) {
+			res.reject("required term %q is missing", p.Required[i])
+		}
+	}
+	for i, re := range ignored {
+		if re.MatchString(nzb.Title) {
+			res.reject("ignored term %q is present", p.Ignored[i])
+		}
+	}
+	if nzb.IsTorrent && p.MinSeeders > 0 && nzb.Seeders < p.MinSeeders {
+		res.reject("%d seeders are below the minimum of %d", nzb.Seeders, p.MinSeeders)
+	}
+
+	if i := indexOf(p.PreferredResolutions, resolution); i >= 0 {
+		res.add(ResolutionScore*(len(p.PreferredResolutions)-i), "preferred resolution %s", resolution)
+	}
+	if i := indexOf(p.PreferredSources, source); i >= 0 {
+		res.add(SourceScore*(len(p.PreferredSources)-i), "preferred source %s", source)
+	}
+	if res.Release.Group != "" && indexOf(p.PreferredGroups, res.Release.Group) >= 0 {
+		res.add(GroupScore, "preferred group %s", res.Release.Group)
+	}
+	if p.PreferFreeleech && nzb.IsFreeleech() {
+		res.add(FreeleechScore, "freeleech")
+	}
+	if res.Release.Proper || res.Release.Repack {
+		res.add(RevisionScore, "proper or repack")
+	}
+	popularity := nzb.NumGrabs/10 + nzb.Seeders/10
+	if popularity > MaxPopularityScore {
+		popularity = MaxPopularityScore
+	}
+	if popularity > 0 {
+		res.add(popularity, "%d grabs and %d seeders", nzb.NumGrabs, nzb.Seeders)
+	}
+}
+
+var dimensionsRe = regexp.MustCompile(`^\d{3,4}x(\d{3,4})$`)
+
+// NormalizeResolution turns resolutions reported by indexers such as "1920x1080", "1080i" or "HD 720p" into "1080p" style names
+func NormalizeResolution(resolution string) string {
+	resolution = strings.ToLower(strings.TrimSpace(resolution))
+	if resolution == "" {
+		return ""
+	}
+	if m := dimensionsRe.FindStringSubmatch(resolution); m != nil {
+		height, _ := strconv.Atoi(m[1])
+		switch {
+		case height >= 1600:
+			return "2160p"
+		case height >= 900:
+			return "1080p"
+		case height >= 700:
+			return "720p"
+		case height >= 560:
+			return "576p"
+		default:
+			return "480p"
+		}
+	}
+	return release.Parse(resolution).Resolution
+}
+
+func compileTerms(terms []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(terms))
+	for _, term := range terms {
+		pattern := `(?i)(?:^|[^a-z0-9])` + regexp.QuoteMeta(term) + `(?:$|[^a-z0-9])`
+		if len(term) > 2 && strings.HasPrefix(term, "/") && strings.HasSuffix(term, "/") {
+			pattern = "(?i)" + term[1:len(term)-1]
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid term %q", term)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if strings.EqualFold(v, value) {
+			return i
+		}
+	}
+	return -1
+}
+
+func inCategories(nzbCategories []string, allowed []int) bool {
+	for _, category := range nzbCategories {
+		id, err := strconv.Atoi(category)
+		if err != nil {
+			continue
+		}
+		parent, hasParent := newznab.ParentOf(id)
+		for _, a := range allowed {
+			// A parent category such as 5000 allows all of its subcategories in the standard tree
+			if id == a || (hasParent && parent.ID == a) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package quality
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRank(t *testing.T) {
+	freeleech := 0.0
+	nzbs := []newznab.NZB{
+		{ID: "sd", Title: "Oldboy.2003.DVDRip.XviD-GRP", Size: 700 << 20, Category: []string{"2030"}},
+		{ID: "720p", Title: "Oldboy.2003.720p.BluRay.x264-GRP", Size: 4 << 30, Category: []string{"2040"}, NumGrabs: 300},
+		{ID: "1080p", Title: "Oldboy.2003.1080p.BluRay.x264-SPARKS", Resolution: "1920x1080", Size: 8 << 30, Category: []string{"2040"}},
+		{ID: "huge", Title: "Oldboy.2003.1080p.BluRay.Remux.AVC.DTS-HD.MA-GRP", Size: 40 << 30, Category: []string{"2040"}},
+		{ID: "torrent", Title: "Oldboy.2003.1080p.WEB-DL.x264-GRP", Size: 6 << 30, Category: []string{"2040"}, IsTorrent: true, Seeders: 2, DownloadVolumeFactor: &freeleech},
+		{ID: "hardsub", Title: "Oldboy.2003.1080p.BluRay.HC.x264-GRP", Size: 8 << 30, Category: []string{"2040"}},
+	}
+	profile := Profile{
+		Resolutions:          []string{"720p", "1080p"},
+		PreferredResolutions: []string{"1080p", "720p"},
+		PreferredSources:     []string{"BluRay", "WEB-DL"},
+		Categories:           []int{newznab.CategoryMovieAll},
+		Runtime:              120 * time.Minute,
+		MaxSizePerMinute:     100 << 20,
+		Ignored:              []string{"HC", "/\\bhardsub/"},
+		PreferredGroups:      []string{"SPARKS"},
+		MinSeeders:           5,
+		PreferFreeleech:      true,
+	}
+
+	results, err := Rank(nzbs, profile)
+	require.NoError(t, err)
+	require.Len(t, results, len(nzbs))
+
+	t.Run("ordering", func(t *testing.T) {
+		accepted := Accepted(results)
+		require.Len(t, accepted, 2)
+		require.Equal(t, "1080p", accepted[0].NZB.ID)
+		require.Equal(t, "720p", accepted[1].NZB.ID)
+		require.Equal(t, ResolutionScore*2+SourceScore*2+GroupScore, accepted[0].Score)
+		require.Equal(t, ResolutionScore+SourceScore*2+MaxPopularityScore, accepted[1].Score)
+	})
+
+	t.Run("rejections", func(t *testing.T) {
+		reasons := map[string][]string{}
+		for _, res := range results[2:] {
+			require.True(t, res.Rejected)
+			reasons[res.NZB.ID] = res.Reasons
+		}
+		require.Contains(t, reasons["sd"], `rejected: resolution "" is not allowed`)
+		require.Contains(t, reasons["huge"], "rejected: size of 357913941 bytes per minute is above 104857600")
+		require.Contains(t, reasons["torrent"], "rejected: 2 seeders are below the minimum of 5")
+		require.Contains(t, reasons["torrent"], "+25 freeleech")
+		require.Contains(t, reasons["hardsub"], `rejected: ignored term "HC" is present`)
+	})
+
+	t.Run("categories", func(t *testing.T) {
+		nzbs := []newznab.NZB{
+			{ID: "misc", Title: "Misc.Release-GRP", Category: []string{"8010"}},
+			{ID: "hashed", Title: "Hashed.Release-GRP", Category: []string{"8020"}},
+			{ID: "unlisted", Title: "Unlisted.Release-GRP", Category: []string{"8999"}},
+			{ID: "movie", Title: "Movie.2016.1080p.BluRay.x264-GRP", Category: []string{"2040"}},
+			{ID: "unknown", Title: "Unknown.Release-GRP", Category: []string{"123456"}},
+		}
+		results, err := Rank(nzbs, Profile{Categories: []int{newznab.CategoryOtherAll}})
+		require.NoError(t, err)
+		var accepted []string
+		for _, res := range Accepted(results) {
+			accepted = append(accepted, res.NZB.ID)
+		}
+		require.ElementsMatch(t, []string{"misc", "hashed", "unlisted"}, accepted)
+	})
+
+	t.Run("invalid regex", func(t *testing.T) {
+		_, err := Rank(nzbs, Profile{Required: []string{"/(/"}})
+		require.Error(t, err)
+	})
+}
+
+func TestNormalizeResolution(t *testing.T) {
+	require.Equal(t, "1080p", NormalizeResolution("1920x1080"))
+	require.Equal(t, "720p", NormalizeResolution("1280x720"))
+	require.Equal(t, "2160p", NormalizeResolution("3840x2160"))
+	require.Equal(t, "1080p", NormalizeResolution("1080i"))
+	require.Equal(t, "720p", NormalizeResolution("720p"))
+	require.Equal(t, "", NormalizeResolution(""))
+}
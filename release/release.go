@@ -0,0 +1,437 @@
+// Package release parses scene release titles such as "Bones.S10E22.DVDRip.X264-REWARD"
+// into their title, episode and quality information.
+package release
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+)
+
+// Release holds the information parsed from a release title
+type Release struct {
+	Title string `json:"title,omitempty"`
+	Year  int    `json:"year,omitempty"`
+
+	// Episode information
+	Season          int       `json:"season,omitempty"`
+	Episodes        []int     `json:"episodes,omitempty"`
+	AirDate         time.Time `json:"air_date,omitempty"`
+	AbsoluteEpisode int       `json:"absolute_episode,omitempty"`
+	FullSeason      bool      `json:"full_season,omitempty"`
+
+	// Quality information
+	Resolution string   `json:"resolution,omitempty"`
+	Source     string   `json:"source,omitempty"`
+	VideoCodec string   `json:"video_codec,omitempty"`
+	AudioCodec string   `json:"audio_codec,omitempty"`
+	HDR        []string `json:"hdr,omitempty"`
+	Edition    string   `json:"edition,omitempty"`
+	Proper     bool     `json:"proper,omitempty"`
+	Repack     bool     `json:"repack,omitempty"`
+	Languages  []string `json:"languages,omitempty"`
+
+	Group string `json:"group,omitempty"`
+}
+
+// IsEpisode reports whether the release is a TV episode or season
+func (r Release) IsEpisode() bool {
+	return r.Season > 0 || len(r.Episodes) > 0 || !r.AirDate.IsZero() || r.AbsoluteEpisode > 0
+}
+
+// IsDaily reports whether the release is an episode of a daily show identified by its air date
+func (r Release) IsDaily() bool {
+	return !r.AirDate.IsZero()
+}
+
+// Fill sets the fields of the given NZB that the indexer left blank from this release
+// and brings its MediaIDs in line with its id fields
+func (r Release) Fill(nzb *newznab.NZB) {
+	nzb.SyncMediaIDs()
+	if nzb.Resolution == "" {
+		nzb.Resolution = r.Resolution
+	}
+	if !r.IsEpisode() {
+		return
+	}
+	if nzb.TVTitle == "" {
+		nzb.TVTitle = r.Title
+	}
+	switch {
+	case r.IsDaily():
+		if nzb.Season == "" {
+			nzb.Season = strconv.Itoa(r.AirDate.Year())
+		}
+		if nzb.Episode == "" {
+			nzb.Episode = r.AirDate.Format("01/02")
+		}
+	case r.Season > 0:
+		if nzb.Season == "" {
+			nzb.Season = fmt.Sprintf("S%02d", r.Season)
+		}
+		if nzb.Episode == "" && len(r.Episodes) > 0 {
+			nzb.Episode = fmt.Sprintf("E%02d", r.Episodes[0])
+		}
+	}
+	r.fillNumbers(nzb)
+}
+
+// fillNumbers sets the numbered episode fields the indexer attributes didn't provide
+func (r Release) fillNumbers(nzb *newznab.NZB) {
+	if nzb.SeasonNumber != 0 || len(nzb.EpisodeNumbers) > 0 || nzb.AbsoluteEpisode != 0 || !nzb.AirDateEpisode.IsZero() {
+		return
+	}
+	switch {
+	case r.IsDaily():
+		nzb.AirDateEpisode = r.AirDate
+	case r.Season > 0:
+		nzb.SeasonNumber = r.Season
+		nzb.EpisodeNumbers = append([]int(nil), r.Episodes...)
+	case r.AbsoluteEpisode > 0:
+		nzb.AbsoluteEpisode = r.AbsoluteEpisode
+	}
+}
+
+// ParseNZB parses the title of the given NZB and fills in the fields the indexer left blank
+func ParseNZB(nzb *newznab.NZB) Release {
+	r := Parse(nzb.Title)
+	r.Fill(nzb)
+	return r
+}
+
+type tag struct {
+	re    *regexp.Regexp
+	value string
+	// weak tags are also common words in titles and only count once the title has ended
+	weak bool
+}
+
+const (
+	sep   = `[\s._\[\]()+-]`
+	start = `(?i)(?:^|` + sep + `)(`
+	end   = `)(?:$|` + sep + `)`
+)
+
+func tagRegexp(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(start + pattern + end)
+}
+
+func strong(pattern string, value string) tag {
+	return tag{re: tagRegexp(pattern), value: value}
+}
+
+func weak(pattern string, value string) tag {
+	return tag{re: tagRegexp(pattern), value: value, weak: true}
+}
+
+var (
+	resolutions = []tag{
+		strong(`2160p|4k|uhd`, "2160p"),
+		strong(`1080p|1080i`, "1080p"),
+		strong(`720p`, "720p"),
+		strong(`576p`, "576p"),
+		strong(`480p`, "480p"),
+	}
+	sources = []tag{
+		strong(`blu-?ray|bdrip|brrip|bd25|bd50|avchd`, "BluRay"),
+		strong(`web-?dl|webdl|amzn|dsnp|hmax|atvp`, "WEB-DL"),
+		strong(`web-?rip`, "WEBRip"),
+		weak(`web|nf`, "WEB-DL"),
+		strong(`hdtv`, "HDTV"),
+		strong(`pdtv|sdtv|dsr|tvrip`, "SDTV"),
+		strong(`dvd-?rip`, "DVDRip"),
+		strong(`dvd[59]|dvd-?r`, "DVD"),
+		weak(`dvd|ntsc|pal`, "DVD"),
+		strong(`hd-?rip`, "HDRip"),
+		strong(`hdcam|camrip`, "CAM"),
+		weak(`cam`, "CAM"),
+		strong(`hdts|telesync`, "TS"),
+		weak(`ts`, "TS"),
+		strong(`telecine`, "TC"),
+		weak(`tc`, "TC"),
+		strong(`dvdscr|screener`, "SCR"),
+		weak(`scr`, "SCR"),
+	}
+	videoCodecs = []tag{
+		strong(`x\.?264|h\.?264`, "H.264"),
+		weak(`avc`, "H.264"),
+		strong(`x\.?265|h\.?265|hevc`, "H.265"),
+		strong(`xvid`, "XviD"),
+		strong(`divx`, "DivX"),
+		strong(`av1`, "AV1"),
+		strong(`vp9`, "VP9"),
+		strong(`vc-?1`, "VC-1"),
+		strong(`mpeg-?2`, "MPEG-2"),
+	}
+	audioCodecs = []tag{
+		strong(`atmos`, "Atmos"),
+		strong(`truehd`, "TrueHD"),
+		strong(`dts-?hd[.\s]?ma|dts-?ma`, "DTS-HD MA"),
+		strong(`dts-?x`, "DTS:X"),
+		strong(`dts-?hd`, "DTS-HD"),
+		strong(`dts(?:-?es)?`, "DTS"),
+		strong(`e-?ac-?3|ddp(?:\d\.?\d)?|dd\+(?:\d\.?\d)?`, "EAC3"),
+		strong(`ac-?3|dd\d\.?\d`, "AC3"),
+		strong(`aac(?:\d\.?\d)?`, "AAC"),
+		strong(`flac`, "FLAC"),
+		strong(`lpcm`, "PCM"),
+		weak(`pcm`, "PCM"),
+		weak(`opus`, "Opus"),
+		strong(`mp3`, "MP3"),
+	}
+	hdrFormats = []tag{
+		strong(`hdr10\+|hdr10plus`, "HDR10+"),
+		strong(`hdr10`, "HDR10"),
+		strong(`hdr`, "HDR"),
+		strong(`dovi|dolby[.\s]?vision`, "DV"),
+		weak(`dv`, "DV"),
+		weak(`hlg`, "HLG"),
+	}
+	editions = []tag{
+		weak(`extended(?:[.\s](?:cut|edition))?`, "Extended"),
+		strong(`directors?'?s?[.\s]cut`, "Director's Cut"),
+		weak(`theatrical(?:[.\s](?:cut|edition))?`, "Theatrical"),
+		weak(`unrated`, "Unrated"),
+		weak(`uncut`, "Uncut"),
+		weak(`remastered`, "Remastered"),
+		weak(`imax`, "IMAX"),
+		weak(`criterion`, "Criterion"),
+		weak(`final[.\s]cut`, "Final Cut"),
+		weak(`ultimate[.\s](?:cut|edition)`, "Ultimate"),
+		weak(`special[.\s]edition`, "Special Edition"),
+	}
+	languages = []tag{
+		strong(`multi`, "Multi"),
+		strong(`truefrench|vff|vfq`, "French"),
+		weak(`french`, "French"),
+		weak(`german|deutsch`, "German"),
+		weak(`italian|ita`, "Italian"),
+		weak(`spanish|castellano`, "Spanish"),
+		weak(`dutch|flemish`, "Dutch"),
+		weak(`swedish`, "Swedish"),
+		weak(`danish`, "Danish"),
+		weak(`norwegian`, "Norwegian"),
+		weak(`finnish`, "Finnish"),
+		weak(`nordic`, "Nordic"),
+		weak(`polish`, "Polish"),
+		weak(`russian|rus`, "Russian"),
+		weak(`portuguese`, "Portuguese"),
+		weak(`japanese`, "Japanese"),
+		weak(`korean`, "Korean"),
+		weak(`chinese`, "Chinese"),
+		weak(`hindi`, "Hindi"),
+		strong(`vostfr`, "French Subbed"),
+	}
+
+	tagSets = [][]tag{resolutions, sources, videoCodecs, audioCodecs, hdrFormats, editions, languages}
+
+	remuxRe  = tagRegexp(`remux`)
+	properRe = tagRegexp(`proper`)
+	repackRe = tagRegexp(`repack|rerip`)
+
+	extensionRe    = regexp.MustCompile(`(?i)\.(?:nzb|mkv|mp4|avi|m4v|ts|torrent)$`)
+	leadingGroupRe = regexp.MustCompile(`^\[([^\]]+)\]\s*`)
+	trailingTagRe  = regexp.MustCompile(`\s*\[[^\]]*\]$`)
+	groupRe        = regexp.MustCompile(`-([^\s.\-\[\]()]+)(?:-\d)?$`)
+	yearRe         = tagRegexp(`(?:19|20)\d{2}`)
+	episodeRe      = regexp.MustCompile(start + `s(\d{1,4})[\s.]?e(\d{1,4})((?:-?e\d{1,4}|-\d{1,4})*)` + end)
+	crossEpisodeRe = regexp.MustCompile(start + `(\d{1,2})x(\d{2,3})` + end)
+	dailyRe        = regexp.MustCompile(start + `((?:19|20)\d{2})[\s.-](\d{2})[\s.-](\d{2})` + end)
+	seasonRe       = regexp.MustCompile(start + `s(\d{1,2})|season[\s.](\d{1,2})` + end)
+	absoluteRe     = regexp.MustCompile(`\s-\s(\d{1,4})(?:v\d)?(?:\s|$)`)
+	moreEpisodesRe = regexp.MustCompile(`(?i)-?e?(\d{1,4})`)
+	separatorRe    = regexp.MustCompile(`[\s._]+`)
+)
+
+// Parse parses the given release title
+func Parse(title string) Release {
+	var r Release
+	s := strings.TrimSpace(extensionRe.ReplaceAllString(strings.TrimSpace(title), ""))
+
+	// Anime releases put their group in front, e.g. "[SubsPlease] One Piece - 1071 (1080p) [ABCD1234]"
+	anime := false
+	if m := leadingGroupRe.FindStringSubmatch(s); m != nil {
+		r.Group = m[1]
+		s = s[len(m[0]):]
+		anime = true
+	}
+	for trailingTagRe.MatchString(s) {
+		s = trailingTagRe.ReplaceAllString(s, "")
+	}
+
+	// marker is where the title ends and the tags start
+	marker := len(s)
+	mark := func(pos int) {
+		if pos >= 0 && pos < marker {
+			marker = pos
+		}
+	}
+
+	switch {
+	case episodeRe.MatchString(s):
+		m := episodeRe.FindStringSubmatchIndex(s)
+		r.Season = atoi(s[m[4]:m[5]])
+		r.Episodes = parseEpisodes(atoi(s[m[6]:m[7]]), s[m[8]:m[9]])
+		mark(m[2])
+	case dailyRe.MatchString(s):
+		m := dailyRe.FindStringSubmatchIndex(s)
+		date, err := time.Parse("2006-01-02", s[m[4]:m[5]]+"-"+s[m[6]:m[7]]+"-"+s[m[8]:m[9]])
+		if err == nil {
+			r.AirDate = date
+			mark(m[2])
+		}
+	case crossEpisodeRe.MatchString(s):
+		m := crossEpisodeRe.FindStringSubmatchIndex(s)
+		r.Season = atoi(s[m[4]:m[5]])
+		r.Episodes = []int{atoi(s[m[6]:m[7]])}
+		mark(m[2])
+	case seasonRe.MatchString(s):
+		m := seasonRe.FindStringSubmatchIndex(s)
+		if m[4] >= 0 {
+			r.Season = atoi(s[m[4]:m[5]])
+		} else {
+			r.Season = atoi(s[m[6]:m[7]])
+		}
+		r.FullSeason = true
+		mark(m[2])
+	case anime && absoluteRe.MatchString(s):
+		m := absoluteRe.FindStringSubmatchIndex(s)
+		r.AbsoluteEpisode = atoi(s[m[2]:m[3]])
+		mark(m[0])
+	}
+
+	// Strong tags can only be part of the release information so they end the title
+	for _, set := range tagSets {
+		for _, candidate := range set {
+			if pos := findTag(candidate.re, s, 0); pos >= 0 && !candidate.weak {
+				mark(pos)
+			}
+		}
+	}
+	if pos := findTag(properRe, s, 0); pos >= 0 {
+		r.Proper = true
+		mark(pos)
+	}
+	if pos := findTag(repackRe, s, 0); pos >= 0 {
+		r.Repack = true
+		mark(pos)
+	}
+	if !anime {
+		if m := groupRe.FindStringSubmatchIndex(s); m != nil && m[0] > 0 && !isTag(s[m[2]:m[3]]) {
+			r.Group = s[m[2]:m[3]]
+			mark(m[0])
+		}
+	}
+
+	// The year is the last one before the tags, unless it starts the title as in "2001 A Space Odyssey 1968"
+	titleEnd := marker
+	for pos := findTag(yearRe, s[:marker], 1); pos >= 0; pos = findTag(yearRe, s[:marker], pos+1) {
+		r.Year = atoi(s[pos : pos+4])
+		titleEnd = pos
+	}
+	if r.IsDaily() {
+		r.Year = 0
+	}
+	r.Title = cleanTitle(s[:titleEnd])
+
+	// Weak tags are only looked for after the title
+	r.Resolution = firstTag(s, resolutions, titleEnd)
+	r.Source = firstTag(s, sources, titleEnd)
+	if findTag(remuxRe, s, titleEnd) >= 0 {
+		r.Source = "Remux"
+	}
+	r.VideoCodec = firstTag(s, videoCodecs, titleEnd)
+	r.AudioCodec = firstTag(s, audioCodecs, titleEnd)
+	r.Edition = firstTag(s, editions, titleEnd)
+	r.HDR = allTags(s, hdrFormats, titleEnd)
+	r.Languages = allTags(s, languages, titleEnd)
+	return r
+}
+
+func parseEpisodes(first int, rest string) []int {
+	episodes := []int{first}
+	for _, m := range moreEpisodesRe.FindAllStringSubmatch(rest, -1) {
+		next := atoi(m[1])
+		last := episodes[len(episodes)-1]
+		if strings.HasPrefix(m[0], "-") && next > last {
+			// A range such as S01E01-03 or S01E01-E03
+			for ep := last + 1; ep <= next; ep++ {
+				episodes = append(episodes, ep)
+			}
+		} else {
+			episodes = append(episodes, next)
+		}
+	}
+	return episodes
+}
+
+// findTag returns the position of the first match of re in s starting at or after from, or -1
+func findTag(re *regexp.Regexp, s string, from int) int {
+	for offset := 0; offset <= len(s); {
+		m := re.FindStringSubmatchIndex(s[offset:])
+		if m == nil {
+			return -1
+		}
+		if pos := offset + m[2]; pos >= from {
+			return pos
+		}
+		// Continue right after the matched tag so its trailing separator can start the next match
+		offset += m[3]
+	}
+	return -1
+}
+
+func firstTag(s string, candidates []tag, titleEnd int) string {
+	best, value := -1, ""
+	for _, candidate := range candidates {
+		from := 0
+		if candidate.weak {
+			from = titleEnd
+		}
+		if pos := findTag(candidate.re, s, from); pos >= 0 && (best < 0 || pos < best) {
+			best, value = pos, candidate.value
+		}
+	}
+	return value
+}
+
+func allTags(s string, candidates []tag, titleEnd int) []string {
+	var values []string
+	seen := map[string]bool{}
+	for _, candidate := range candidates {
+		from := 0
+		if candidate.weak {
+			from = titleEnd
+		}
+		if pos := findTag(candidate.re, s, from); pos >= 0 && !seen[candidate.value] {
+			seen[candidate.value] = true
+			values = append(values, candidate.value)
+		}
+	}
+	return values
+}
+
+func isTag(s string) bool {
+	for _, set := range tagSets {
+		for _, candidate := range set {
+			if m := candidate.re.FindStringSubmatchIndex(s); m != nil && m[2] == 0 && m[3] == len(s) {
+				return true
+			}
+		}
+	}
+	return strings.EqualFold(s, "dl") || strings.EqualFold(s, "ma") || strings.EqualFold(s, "hd")
+}
+
+func cleanTitle(s string) string {
+	s = separatorRe.ReplaceAllString(s, " ")
+	return strings.Trim(s, " -([")
+}
+
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
@@ -0,0 +1,153 @@
+package release
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("tv episode", func(t *testing.T) {
+		r := Parse("Bones.S10E22.DVDRip.X264-REWARD")
+		require.Equal(t, "Bones", r.Title)
+		require.Equal(t, 10, r.Season)
+		require.Equal(t, []int{22}, r.Episodes)
+		require.Equal(t, "DVDRip", r.Source)
+		require.Equal(t, "H.264", r.VideoCodec)
+		require.Equal(t, "REWARD", r.Group)
+		require.True(t, r.IsEpisode())
+	})
+
+	t.Run("episode title", func(t *testing.T) {
+		r := Parse("Bones.S10E13.The.Baker.in.the.Bits.INTERNAL.HDTV.x264-FiHTV")
+		require.Equal(t, "Bones", r.Title)
+		require.Equal(t, "HDTV", r.Source)
+		require.Equal(t, "FiHTV", r.Group)
+	})
+
+	t.Run("multi episode", func(t *testing.T) {
+		require.Equal(t, []int{1, 2}, Parse("Show.Name.S01E01E02.720p.HDTV.x264-GRP").Episodes)
+		r := Parse("Show.Name.S01E01-E03.1080p.WEB-DL.DDP5.1.H.264-NTb")
+		require.Equal(t, []int{1, 2, 3}, r.Episodes)
+		require.Equal(t, "WEB-DL", r.Source)
+		require.Equal(t, "EAC3", r.AudioCodec)
+		require.Equal(t, "NTb", r.Group)
+	})
+
+	t.Run("alternative episode format", func(t *testing.T) {
+		r := Parse("Show Name 1x02 HDTV XviD-GRP")
+		require.Equal(t, "Show Name", r.Title)
+		require.Equal(t, 1, r.Season)
+		require.Equal(t, []int{2}, r.Episodes)
+		require.Equal(t, "XviD", r.VideoCodec)
+	})
+
+	t.Run("full season", func(t *testing.T) {
+		r := Parse("Show.Name.S02.1080p.BluRay.x264-GRP")
+		require.Equal(t, 2, r.Season)
+		require.Empty(t, r.Episodes)
+		require.True(t, r.FullSeason)
+	})
+
+	t.Run("media ids", func(t *testing.T) {
+		nzb := newznab.NZB{Title: "Show.Name.S01E05.720p.HDTV.x264-GRP", TVDBID: "75682"}
+		ParseNZB(&nzb)
+		require.Equal(t, 75682, nzb.MediaIDs.TVDB)
+	})
+
+	t.Run("daily show", func(t *testing.T) {
+		r := Parse("The.Daily.Show.2017.03.05.Guest.720p.WEB.h264-TBS")
+		require.Equal(t, "The Daily Show", r.Title)
+		require.Equal(t, time.Date(2017, 3, 5, 0, 0, 0, 0, time.UTC), r.AirDate)
+		require.Zero(t, r.Year)
+		require.True(t, r.IsDaily())
+		require.Equal(t, "WEB-DL", r.Source)
+	})
+
+	t.Run("anime absolute numbering", func(t *testing.T) {
+		r := Parse("[SubsPlease] One Piece - 1071 (1080p) [ABCD1234].mkv")
+		require.Equal(t, "One Piece", r.Title)
+		require.Equal(t, 1071, r.AbsoluteEpisode)
+		require.Equal(t, "1080p", r.Resolution)
+		require.Equal(t, "SubsPlease", r.Group)
+	})
+
+	t.Run("movie", func(t *testing.T) {
+		r := Parse("Iron.Man.2008.BluRay.1080p.TrueHD.h264.Remux-decibeL")
+		require.Equal(t, "Iron Man", r.Title)
+		require.Equal(t, 2008, r.Year)
+		require.Equal(t, "1080p", r.Resolution)
+		require.Equal(t, "Remux", r.Source)
+		require.Equal(t, "TrueHD", r.AudioCodec)
+		require.False(t, r.IsEpisode())
+	})
+
+	t.Run("year in title", func(t *testing.T) {
+		r := Parse("2001.A.Space.Odyssey.1968.2160p.UHD.BluRay.x265.HDR10.DV.TrueHD.Atmos-GRP")
+		require.Equal(t, "2001 A Space Odyssey", r.Title)
+		require.Equal(t, 1968, r.Year)
+		require.Equal(t, "2160p", r.Resolution)
+		require.Equal(t, "H.265", r.VideoCodec)
+		require.Equal(t, []string{"HDR10", "DV"}, r.HDR)
+		require.Equal(t, "GRP", r.Group)
+	})
+
+	t.Run("title words that are also tags", func(t *testing.T) {
+		r := Parse("French.Kiss.1995.FRENCH.DVDRip.XviD-GRP")
+		require.Equal(t, "French Kiss", r.Title)
+		require.Equal(t, []string{"French"}, r.Languages)
+		require.Equal(t, "Charlottes Web", Parse("Charlottes.Web.2006.PROPER.720p.BluRay.x264-GRP").Title)
+	})
+
+	t.Run("proper, repack, edition and language", func(t *testing.T) {
+		require.True(t, Parse("Charlottes.Web.2006.PROPER.720p.BluRay.x264-GRP").Proper)
+		require.True(t, Parse("Iron.Man.REPACK.720p.BluRay.x264-SEPTiC").Repack)
+		require.Equal(t, "Uncut", Parse("Iron.Man.2008-uncut.-.AVCHD-1080p.Ge").Edition)
+		r := Parse("Iron.Man.MULTi.1080p.BluRay.x264-ForceBleue")
+		require.Equal(t, "Iron Man", r.Title)
+		require.Equal(t, []string{"Multi"}, r.Languages)
+	})
+
+	t.Run("indexer duplicate suffix", func(t *testing.T) {
+		r := Parse("Iron.Man.1.2008.1080p.BluRay.DTS.x264-CyTSuNee-1")
+		require.Equal(t, "Iron Man 1", r.Title)
+		require.Equal(t, "CyTSuNee", r.Group)
+	})
+}
+
+func TestFill(t *testing.T) {
+	t.Run("blank fields", func(t *testing.T) {
+		nzb := newznab.NZB{Title: "Show.Name.S01E05.720p.HDTV.x264-GRP"}
+		ParseNZB(&nzb)
+		require.Equal(t, "720p", nzb.Resolution)
+		require.Equal(t, "S01", nzb.Season)
+		require.Equal(t, "E05", nzb.Episode)
+		require.Equal(t, "Show Name", nzb.TVTitle)
+		require.Equal(t, 1, nzb.SeasonNumber)
+		require.Equal(t, []int{5}, nzb.EpisodeNumbers)
+	})
+
+	t.Run("daily show", func(t *testing.T) {
+		nzb := newznab.NZB{Title: "The.Daily.Show.2017.03.05.Guest.720p.WEB.h264-TBS"}
+		ParseNZB(&nzb)
+		require.Equal(t, "2017", nzb.Season)
+		require.Equal(t, "03/05", nzb.Episode)
+		require.Equal(t, time.Date(2017, 3, 5, 0, 0, 0, 0, time.UTC), nzb.AirDateEpisode)
+	})
+
+	t.Run("absolute episode", func(t *testing.T) {
+		nzb := newznab.NZB{Title: "[SubsPlease] One Piece - 1071 (1080p) [ABCD1234].mkv"}
+		ParseNZB(&nzb)
+		require.Equal(t, 1071, nzb.AbsoluteEpisode)
+	})
+
+	t.Run("indexer fields are kept", func(t *testing.T) {
+		nzb := newznab.NZB{Title: "Show.Name.S01E05.720p.HDTV.x264-GRP", Resolution: "1280x720", Season: "1", SeasonNumber: 1}
+		ParseNZB(&nzb)
+		require.Equal(t, "1280x720", nzb.Resolution)
+		require.Equal(t, "1", nzb.Season)
+		require.Empty(t, nzb.EpisodeNumbers, "numbers parsed from the attributes aren't replaced")
+	})
+}
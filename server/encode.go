@@ -0,0 +1,160 @@
+package server
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/pkg/errors"
+)
+
+// NewFeed returns the RSS feed for the given NZBs
+func NewFeed(title string, description string, nzbs []newznab.NZB, offset int, total int) newznab.SearchResponse {
+	var feed newznab.SearchResponse
+	feed.Version = "2.0"
+	feed.Channel.Title = title
+	feed.Channel.Description = description
+	feed.Channel.Link.Rel = "self"
+	feed.Channel.Link.Type = "application/rss+xml"
+	feed.Channel.Response.Offset = offset
+	feed.Channel.Response.Total = total
+	feed.Channel.NZBs = make([]newznab.RawNZB, 0, len(nzbs))
+	for _, nzb := range nzbs {
+		feed.Channel.NZBs = append(feed.Channel.NZBs, nzb.Raw())
+	}
+	return feed
+}
+
+// WriteFeed writes the given feed as an <rss> document
+func WriteFeed(w io.Writer, feed newznab.SearchResponse) error {
+	return encode(w, &feed, xml.StartElement{
+		Name: xml.Name{Local: "rss"},
+		Attr: []xml.Attr{
+			{Name: xml.Name{Local: "xmlns:atom"}, Value: newznab.AtomNamespace},
+			{Name: xml.Name{Local: "xmlns:newznab"}, Value: newznab.NewznabNamespace},
+			{Name: xml.Name{Local: "xmlns:torznab"}, Value: newznab.TorznabNamespace},
+		},
+	})
+}
+
+// WriteCapabilities writes the given capabilities as a <caps> document
+func WriteCapabilities(w io.Writer, caps newznab.Capabilities) error {
+	return encode(w, &caps, xml.StartElement{Name: xml.Name{Local: "caps"}})
+}
+
+// WriteError writes the given error as an <error> document
+func WriteError(w io.Writer, apiErr *newznab.APIError) error {
+	return encode(w, apiErr, xml.StartElement{Name: xml.Name{Local: "error"}})
+}
+
+type commentFeed struct {
+	Version string        `xml:"version,attr"`
+	Items   []commentItem `xml:"channel>item"`
+}
+
+type commentItem struct {
+	Title       string              `xml:"title"`
+	GUID        string              `xml:"guid,omitempty"`
+	Author      string              `xml:"author,omitempty"`
+	Description string              `xml:"description"`
+	PubDate     newznab.Time        `xml:"pubDate"`
+	Attributes  []newznab.Attribute `xml:"attr"`
+}
+
+// WriteComments writes the given comments as an <rss> document
+func WriteComments(w io.Writer, comments []newznab.Comment) error {
+	feed := commentFeed{Version: "2.0"}
+	attrName := xml.Name{Space: newznab.NewznabNamespace, Local: "attr"}
+	for _, comment := range comments {
+		item := commentItem{
+			Title:       comment.Title,
+			GUID:        comment.GUID,
+			Author:      comment.Author,
+			Description: comment.Content,
+			PubDate:     newznab.Time{Time: comment.PubDate},
+		}
+		if comment.ID != "" {
+			item.Attributes = append(item.Attributes, newznab.Attribute{XMLName: attrName, Name: "id", Value: comment.ID})
+		}
+		if comment.Rating != 0 {
+			item.Attributes = append(item.Attributes, newznab.Attribute{XMLName: attrName, Name: "rating", Value: strconv.Itoa(comment.Rating)})
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return encode(w, &feed, xml.StartElement{
+		Name: xml.Name{Local: "rss"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns:newznab"}, Value: newznab.NewznabNamespace}},
+	})
+}
+
+// actionResult is the response to api functions like commentadd and cartadd
+type actionResult struct {
+	ID string `xml:"id,attr"`
+}
+
+// WriteCommentAdded writes the response to t=commentadd for the comment with the given id
+func WriteCommentAdded(w io.Writer, id string) error {
+	return writeAction(w, "commentadd", id)
+}
+
+func writeAction(w io.Writer, function string, id string) error {
+	return encode(w, &actionResult{ID: id}, xml.StartElement{Name: xml.Name{Local: function}})
+}
+
+type userElement struct {
+	Username         string `xml:"username,attr,omitempty"`
+	Role             string `xml:"role,attr,omitempty"`
+	Grabs            int    `xml:"grabs,attr"`
+	APIRequests      int    `xml:"apirequests,attr"`
+	APILimit         int    `xml:"apilimit,attr,omitempty"`
+	DownloadRequests int    `xml:"downloadrequests,attr"`
+	DownloadLimit    int    `xml:"downloadlimit,attr,omitempty"`
+	Expires          string `xml:"expires,attr,omitempty"`
+}
+
+// WriteUser writes the given account as a <user> document
+func WriteUser(w io.Writer, user newznab.UserInfo) error {
+	elem := userElement{
+		Username:         user.Username,
+		Role:             user.Role,
+		Grabs:            user.Grabs,
+		APIRequests:      user.APIRequests,
+		APILimit:         user.APILimit,
+		DownloadRequests: user.DownloadRequests,
+		DownloadLimit:    user.DownloadLimit,
+	}
+	if !user.Expires.IsZero() {
+		elem.Expires = user.Expires.UTC().Format(time.RFC3339)
+	}
+	return encode(w, &elem, xml.StartElement{Name: xml.Name{Local: "user"}})
+}
+
+type registerElement struct {
+	Username string `xml:"username,attr"`
+	Password string `xml:"password,attr"`
+	APIKey   string `xml:"apikey,attr"`
+	UserID   int    `xml:"userid,attr,omitempty"`
+}
+
+// WriteRegistration writes the given new account as a <register> document
+func WriteRegistration(w io.Writer, reg newznab.Registration) error {
+	elem := registerElement(reg)
+	return encode(w, &elem, xml.StartElement{Name: xml.Name{Local: "register"}})
+}
+
+func encode(w io.Writer, v interface{}, start xml.StartElement) error {
+	if rw, ok := w.(http.ResponseWriter); ok {
+		rw.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return errors.Wrap(err, "failed to write xml header")
+	}
+	enc := xml.NewEncoder(w)
+	if err := enc.EncodeElement(v, start); err != nil {
+		return errors.Wrap(err, "failed to encode xml")
+	}
+	return errors.Wrap(enc.Flush(), "failed to flush xml")
+}
@@ -0,0 +1,518 @@
+// Package server exposes a catalog as a newznab compatible indexer so tools like Sonarr and Radarr can consume it.
+package server
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Errors a Backend can return to produce the matching newznab <error> response.
+// Any *newznab.APIError is passed through, also when wrapped with errors.Wrap, other errors are reported as unknown errors.
+var (
+	ErrNotFound     = newznab.NewAPIError(newznab.ErrorNoSuchItem)
+	ErrNotSupported = newznab.NewAPIError(newznab.ErrorFunctionNotAvailable)
+)
+
+// cartFeed is the value of t on the rss endpoint that selects the cart of the user
+const cartFeed = "-2"
+
+// Query is a search request received by the server
+type Query struct {
+	// Type is the requested function: "search", "tvsearch", "movie" or "rss"
+	Type       string
+	Query      string
+	Categories []int
+	Season     string
+	Episode    string
+	TVRageID   string
+	TVDBID     string
+	TVMazeID   string
+	IMDBID     string
+	Limit      int
+	Offset     int
+	// IDs has every id parameter of the request, including tmdbid, traktid and doubanid, with a normalized imdb id
+	IDs newznab.MediaIDs
+	// Params holds all query parameters for backends that support more than the standard ones
+	Params url.Values
+}
+
+// Results is a page of search results
+type Results struct {
+	NZBs []newznab.NZB
+	// Total is the number of results across all pages, defaults to the offset plus the number of NZBs
+	Total int
+}
+
+// Backend is the catalog served by the Handler
+type Backend interface {
+	Capabilities(ctx context.Context) (newznab.Capabilities, error)
+	Search(ctx context.Context, q Query) (Results, error)
+	Details(ctx context.Context, id string) (newznab.NZB, error)
+	// Download returns the NZB file of the given item
+	Download(ctx context.Context, id string) ([]byte, error)
+	Comments(ctx context.Context, id string) ([]newznab.Comment, error)
+}
+
+// Carts is implemented by backends that keep a cart of items for each api key
+type Carts interface {
+	CartAdd(ctx context.Context, apikey string, id string) error
+	CartDelete(ctx context.Context, apikey string, id string) error
+	// Cart returns the items in the cart, remove empties the cart of the returned items
+	Cart(ctx context.Context, apikey string, remove bool) ([]newznab.NZB, error)
+}
+
+// Accounts is implemented by backends that report accounts with t=user and create them with t=register
+type Accounts interface {
+	User(ctx context.Context, apikey string) (newznab.UserInfo, error)
+	Register(ctx context.Context, email string) (newznab.Registration, error)
+}
+
+// CommentAdder is implemented by backends that accept comments with t=commentadd
+type CommentAdder interface {
+	// AddComment stores a comment on the given item and returns it with its ID set
+	AddComment(ctx context.Context, id string, text string) (newznab.Comment, error)
+}
+
+// Options configures a Handler
+type Options struct {
+	// Title and Description describe the indexer in feeds
+	Title       string
+	Description string
+	// BaseURL is used for the download links in feeds, defaults to the scheme and host of the request
+	BaseURL string
+	// TrustForwardedProto takes the scheme of the links from the X-Forwarded-Proto header when BaseURL is empty.
+	// Only enable it behind a proxy that overwrites the header, clients can send any value.
+	TrustForwardedProto bool
+	// Authenticate checks an api key, return a *newznab.APIError to choose the error code.
+	// When nil every request is accepted.
+	Authenticate func(apikey string) error
+	// DefaultLimit and MaxLimit bound the number of results per page, both default to 100
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// APIKeys returns an Authenticate function accepting only the given keys
+func APIKeys(keys ...string) func(apikey string) error {
+	allowed := map[string]bool{}
+	for _, key := range keys {
+		allowed[key] = true
+	}
+	return func(apikey string) error {
+		if apikey == "" || !allowed[apikey] {
+			return newznab.NewAPIError(newznab.ErrorIncorrectCredentials)
+		}
+		return nil
+	}
+}
+
+// Handler is an http.Handler serving the newznab /api and /rss endpoints
+type Handler struct {
+	backend Backend
+	opts    Options
+}
+
+// New returns a Handler for the given backend.
+// Requests are routed by the last path element so the handler can be mounted under any prefix.
+func New(backend Backend, opts Options) *Handler {
+	if opts.DefaultLimit <= 0 {
+		opts.DefaultLimit = 100
+	}
+	if opts.MaxLimit <= 0 {
+		opts.MaxLimit = 100
+	}
+	if opts.Title == "" {
+		opts.Title = "go-newznab"
+	}
+	return &Handler{backend: backend, opts: opts}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch path.Base(r.URL.Path) {
+	case "api":
+		h.serveAPI(w, r)
+	case "rss":
+		h.serveRSS(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) serveAPI(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	t := params.Get("t")
+	if t == "" {
+		h.writeError(w, newznab.NewAPIError(newznab.ErrorMissingParameter))
+		return
+	}
+	// Capabilities are public so clients can discover the indexer before configuring a key
+	if t == "caps" {
+		h.serveCaps(w, r)
+		return
+	}
+	// Registration creates the key so it can't require one
+	if t == "register" {
+		h.serveRegister(w, r, params.Get("email"))
+		return
+	}
+	if err := h.authenticate(params.Get("apikey")); err != nil {
+		h.writeError(w, err)
+		return
+	}
+
+	switch t {
+	case "search", "tvsearch", "movie":
+		q, err := h.parseQuery(t, params, "limit")
+		if err != nil {
+			h.writeError(w, err)
+			return
+		}
+		h.serveSearch(w, r, q)
+	case "details":
+		h.serveDetails(w, r, itemID(params))
+	case "get":
+		h.serveGet(w, r, itemID(params))
+	case "comments":
+		h.serveComments(w, r, itemID(params))
+	case "commentadd":
+		h.serveCommentAdd(w, r, itemID(params))
+	case "user":
+		h.serveUser(w, r, params.Get("apikey"))
+	case "cartadd", "cartdel":
+		h.serveCartChange(w, r, t, params.Get("apikey"), itemID(params))
+	default:
+		h.writeError(w, newznab.NewAPIError(newznab.ErrorNoSuchFunction))
+	}
+}
+
+func (h *Handler) serveRSS(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	if err := h.authenticate(params.Get("r")); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if params.Get("t") == cartFeed {
+		h.serveCart(w, r, params.Get("r"), params.Get("del") == "1")
+		return
+	}
+	// The rss endpoint passes its categories in t
+	params.Set("cat", params.Get("t"))
+	q, err := h.parseQuery("rss", params, "num")
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.serveSearch(w, r, q)
+}
+
+func (h *Handler) serveCaps(w http.ResponseWriter, r *http.Request) {
+	caps, err := h.backend.Capabilities(r.Context())
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if err := WriteCapabilities(w, caps); err != nil {
+		log.WithError(err).Debug("failed to write capabilities")
+	}
+}
+
+func (h *Handler) serveSearch(w http.ResponseWriter, r *http.Request, q Query) {
+	res, err := h.backend.Search(r.Context(), q)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if res.Total == 0 {
+		res.Total = q.Offset + len(res.NZBs)
+	}
+	h.writeFeed(w, r, res.NZBs, q.Offset, res.Total)
+}
+
+func (h *Handler) serveDetails(w http.ResponseWriter, r *http.Request, id string) {
+	if id == "" {
+		h.writeError(w, newznab.NewAPIError(newznab.ErrorMissingParameter))
+		return
+	}
+	nzb, err := h.backend.Details(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeFeed(w, r, []newznab.NZB{nzb}, 0, 1)
+}
+
+func (h *Handler) serveGet(w http.ResponseWriter, r *http.Request, id string) {
+	if id == "" {
+		h.writeError(w, newznab.NewAPIError(newznab.ErrorMissingParameter))
+		return
+	}
+	data, err := h.backend.Download(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-nzb")
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": id + ".nzb"})
+	if disposition == "" {
+		disposition = "attachment"
+	}
+	w.Header().Set("Content-Disposition", disposition)
+	w.Write(data) // nolint:errcheck
+}
+
+func (h *Handler) serveComments(w http.ResponseWriter, r *http.Request, id string) {
+	if id == "" {
+		h.writeError(w, newznab.NewAPIError(newznab.ErrorMissingParameter))
+		return
+	}
+	params := r.URL.Query()
+	offset, err := intParam(params, "offset", 0)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	limit, err := intParam(params, "limit", 0)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	comments, err := h.backend.Comments(r.Context(), id)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if offset > len(comments) {
+		offset = len(comments)
+	}
+	comments = comments[offset:]
+	if limit > 0 && limit < len(comments) {
+		comments = comments[:limit]
+	}
+	if err := WriteComments(w, comments); err != nil {
+		log.WithError(err).Debug("failed to write comments")
+	}
+}
+
+func (h *Handler) serveCommentAdd(w http.ResponseWriter, r *http.Request, id string) {
+	adder, ok := h.backend.(CommentAdder)
+	if !ok {
+		h.writeError(w, ErrNotSupported)
+		return
+	}
+	text := r.URL.Query().Get("text")
+	if id == "" || text == "" {
+		h.writeError(w, newznab.NewAPIError(newznab.ErrorMissingParameter))
+		return
+	}
+	comment, err := adder.AddComment(r.Context(), id, text)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if err := WriteCommentAdded(w, comment.ID); err != nil {
+		log.WithError(err).Debug("failed to write commentadd response")
+	}
+}
+
+func (h *Handler) serveCartChange(w http.ResponseWriter, r *http.Request, t string, apikey string, id string) {
+	carts, ok := h.backend.(Carts)
+	if !ok {
+		h.writeError(w, ErrNotSupported)
+		return
+	}
+	if id == "" {
+		h.writeError(w, newznab.NewAPIError(newznab.ErrorMissingParameter))
+		return
+	}
+	var err error
+	if t == "cartadd" {
+		err = carts.CartAdd(r.Context(), apikey, id)
+	} else {
+		err = carts.CartDelete(r.Context(), apikey, id)
+	}
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if err := writeAction(w, t, id); err != nil {
+		log.WithError(err).Debugf("failed to write %s response", t)
+	}
+}
+
+func (h *Handler) serveCart(w http.ResponseWriter, r *http.Request, apikey string, remove bool) {
+	carts, ok := h.backend.(Carts)
+	if !ok {
+		h.writeError(w, ErrNotSupported)
+		return
+	}
+	nzbs, err := carts.Cart(r.Context(), apikey, remove)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeFeed(w, r, nzbs, 0, len(nzbs))
+}
+
+func (h *Handler) serveUser(w http.ResponseWriter, r *http.Request, apikey string) {
+	accounts, ok := h.backend.(Accounts)
+	if !ok {
+		h.writeError(w, ErrNotSupported)
+		return
+	}
+	user, err := accounts.User(r.Context(), apikey)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if err := WriteUser(w, user); err != nil {
+		log.WithError(err).Debug("failed to write user")
+	}
+}
+
+func (h *Handler) serveRegister(w http.ResponseWriter, r *http.Request, email string) {
+	accounts, ok := h.backend.(Accounts)
+	if !ok {
+		h.writeError(w, newznab.NewAPIError(newznab.ErrorRegistrationClosed))
+		return
+	}
+	if email == "" {
+		h.writeError(w, newznab.NewAPIError(newznab.ErrorMissingParameter))
+		return
+	}
+	reg, err := accounts.Register(r.Context(), email)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if err := WriteRegistration(w, reg); err != nil {
+		log.WithError(err).Debug("failed to write registration")
+	}
+}
+
+func (h *Handler) authenticate(apikey string) error {
+	if h.opts.Authenticate == nil {
+		return nil
+	}
+	return h.opts.Authenticate(apikey)
+}
+
+func (h *Handler) parseQuery(t string, params url.Values, limitParam string) (Query, error) {
+	q := Query{
+		Type:     t,
+		Query:    params.Get("q"),
+		Season:   params.Get("season"),
+		Episode:  params.Get("ep"),
+		TVRageID: params.Get("rid"),
+		TVDBID:   params.Get("tvdbid"),
+		TVMazeID: params.Get("tvmazeid"),
+		IMDBID:   params.Get("imdbid"),
+		IDs:      newznab.ParseMediaIDs(params),
+		Limit:    h.opts.DefaultLimit,
+		Params:   params,
+	}
+	if q.Episode == "" {
+		q.Episode = params.Get("episode")
+	}
+	for _, cat := range strings.Split(params.Get("cat"), ",") {
+		if cat = strings.TrimSpace(cat); cat == "" {
+			continue
+		}
+		id, err := strconv.Atoi(cat)
+		if err != nil {
+			return q, newznab.NewAPIError(newznab.ErrorIncorrectParameter)
+		}
+		q.Categories = append(q.Categories, id)
+	}
+	var err error
+	if q.Limit, err = intParam(params, limitParam, q.Limit); err != nil {
+		return q, err
+	}
+	if q.Limit > h.opts.MaxLimit {
+		q.Limit = h.opts.MaxLimit
+	}
+	if q.Offset, err = intParam(params, "offset", 0); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+func intParam(params url.Values, name string, def int) (int, error) {
+	raw := params.Get(name)
+	if raw == "" {
+		return def, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, newznab.NewAPIError(newznab.ErrorIncorrectParameter)
+	}
+	return value, nil
+}
+
+func itemID(params url.Values) string {
+	if id := params.Get("id"); id != "" {
+		return id
+	}
+	return params.Get("guid")
+}
+
+func (h *Handler) writeFeed(w http.ResponseWriter, r *http.Request, nzbs []newznab.NZB, offset int, total int) {
+	base := h.baseURL(r)
+	apikey := r.URL.Query().Get("apikey")
+	if apikey == "" {
+		apikey = r.URL.Query().Get("r")
+	}
+	// The backend may return its own catalog, the URLs with the apikey of this request go into a copy
+	items := make([]newznab.NZB, len(nzbs))
+	copy(items, nzbs)
+	for i := range items {
+		if items[i].DownloadURL == "" {
+			items[i].DownloadURL = base + "/api?" + url.Values{
+				"t":      []string{"get"},
+				"id":     []string{items[i].ID},
+				"apikey": []string{apikey},
+			}.Encode()
+		}
+	}
+	feed := NewFeed(h.opts.Title, h.opts.Description, items, offset, total)
+	feed.Channel.Link.Href = base + r.URL.RequestURI()
+	if err := WriteFeed(w, feed); err != nil {
+		log.WithError(err).Debug("failed to write feed")
+	}
+}
+
+func (h *Handler) baseURL(r *http.Request) string {
+	if h.opts.BaseURL != "" {
+		return strings.TrimSuffix(h.opts.BaseURL, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := r.Header.Get("X-Forwarded-Proto"); h.opts.TrustForwardedProto && (forwarded == "http" || forwarded == "https") {
+		scheme = forwarded
+	}
+	base := scheme + "://" + r.Host
+	if dir := path.Dir(r.URL.Path); dir != "/" && dir != "." {
+		base += dir
+	}
+	return base
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	apiErr, ok := errors.Cause(err).(*newznab.APIError)
+	if !ok {
+		log.WithError(err).Error("newznab backend failed")
+		apiErr = newznab.NewAPIError(newznab.ErrorUnknown)
+	}
+	if err := WriteError(w, apiErr); err != nil {
+		log.WithError(err).Debug("failed to write error")
+	}
+}
@@ -0,0 +1,264 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mrobinsn/go-newznab/newznab"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type testBackend struct {
+	nzbs []newznab.NZB
+	last Query
+}
+
+func (b *testBackend) Capabilities(ctx context.Context) (newznab.Capabilities, error) {
+	var caps newznab.Capabilities
+	caps.Server.Title = "test"
+	caps.Searching.Search.Available = "yes"
+	caps.Searching.Search.SupportedParams = "q"
+	caps.Searching.TvSearch.Available = "yes"
+	caps.Searching.TvSearch.SupportedParams = "q,tvdbid,season,ep"
+	return caps, nil
+}
+
+func (b *testBackend) Search(ctx context.Context, q Query) (Results, error) {
+	b.last = q
+	if q.Offset >= len(b.nzbs) {
+		return Results{Total: len(b.nzbs)}, nil
+	}
+	end := q.Offset + q.Limit
+	if end > len(b.nzbs) {
+		end = len(b.nzbs)
+	}
+	return Results{NZBs: append([]newznab.NZB(nil), b.nzbs[q.Offset:end]...), Total: len(b.nzbs)}, nil
+}
+
+func (b *testBackend) Details(ctx context.Context, id string) (newznab.NZB, error) {
+	for _, nzb := range b.nzbs {
+		if nzb.ID == id {
+			return nzb, nil
+		}
+	}
+	return newznab.NZB{}, ErrNotFound
+}
+
+func (b *testBackend) Download(ctx context.Context, id string) ([]byte, error) {
+	if _, err := b.Details(ctx, id); err != nil {
+		return nil, err
+	}
+	return []byte("<nzb/>"), nil
+}
+
+func (b *testBackend) Comments(ctx context.Context, id string) ([]newznab.Comment, error) {
+	return []newznab.Comment{{ID: "c-1", Title: "user", Content: "great", Rating: 8, PubDate: time.Date(2017, 5, 4, 12, 0, 0, 0, time.UTC)}}, nil
+}
+
+func TestHandler(t *testing.T) {
+	backend := &testBackend{}
+	pub := time.Date(2017, 5, 4, 12, 0, 0, 0, time.UTC)
+	for _, id := range []string{"a", "b", "c"} {
+		backend.nzbs = append(backend.nzbs, newznab.NZB{
+			ID:         id,
+			Title:      "Bones.S10E22.DVDRip.X264-REWARD-" + id,
+			Size:       460000000,
+			PubDate:    pub,
+			UsenetDate: pub,
+			Category:   []string{"5000", "5030"},
+			TVDBID:     "75682",
+			Season:     "S10",
+			Episode:    "E22",
+			NumGrabs:   12,
+		})
+	}
+
+	ts := httptest.NewServer(New(backend, Options{
+		Title:        "test indexer",
+		Authenticate: APIKeys("secret"),
+		MaxLimit:     2,
+	}))
+	defer ts.Close()
+
+	client := newznab.New(ts.URL, "secret", 1, false)
+
+	t.Run("caps", func(t *testing.T) {
+		caps, err := client.Capabilities()
+		require.NoError(t, err)
+		require.Equal(t, "test", caps.Server.Title)
+		require.True(t, caps.Supports("tvsearch", "tvdbid"))
+	})
+
+	t.Run("tv search round trip", func(t *testing.T) {
+		results, err := client.SearchWithTVDB([]int{newznab.CategoryTVSD}, 75682, 10, 22)
+		require.NoError(t, err)
+		require.Len(t, results, 2, "limited to the max limit")
+		require.Equal(t, "a", results[0].ID)
+		require.Equal(t, backend.nzbs[0].Title, results[0].Title)
+		require.Equal(t, int64(460000000), results[0].Size)
+		require.Equal(t, []string{"5000", "5030"}, results[0].Category)
+		require.Equal(t, "75682", results[0].TVDBID)
+		require.Equal(t, 12, results[0].NumGrabs)
+		require.True(t, pub.Equal(results[0].PubDate))
+		require.True(t, pub.Equal(results[0].UsenetDate))
+		require.Contains(t, results[0].DownloadURL, ts.URL+"/api?")
+
+		require.Equal(t, "tvsearch", backend.last.Type)
+		require.Equal(t, "75682", backend.last.TVDBID)
+		require.Equal(t, "10", backend.last.Season)
+		require.Equal(t, "22", backend.last.Episode)
+		require.Equal(t, []int{newznab.CategoryTVSD}, backend.last.Categories)
+	})
+
+	t.Run("rss", func(t *testing.T) {
+		results, err := client.LoadRSSFeedUntilNZBID([]int{newznab.CategoryTVAll}, 2, "c", 0)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		require.Equal(t, "rss", backend.last.Type)
+		require.Equal(t, []int{newznab.CategoryTVAll}, backend.last.Categories)
+	})
+
+	t.Run("download", func(t *testing.T) {
+		results, err := client.SearchWithQuery(nil, "bones", "search")
+		require.NoError(t, err)
+		data, err := client.DownloadNZB(results[0])
+		require.NoError(t, err)
+		require.Equal(t, "<nzb/>", string(data))
+	})
+
+	t.Run("details", func(t *testing.T) {
+		details, err := client.Details("b")
+		require.NoError(t, err)
+		require.Equal(t, backend.nzbs[1].Title, details.Channel.Item.Title)
+	})
+
+	t.Run("comments", func(t *testing.T) {
+		nzb := backend.nzbs[0]
+		require.NoError(t, client.PopulateComments(&nzb))
+		require.Len(t, nzb.Comments, 1)
+		require.Equal(t, "great", nzb.Comments[0].Content)
+		require.Equal(t, "c-1", nzb.Comments[0].ID)
+		require.Equal(t, 8, nzb.Comments[0].Rating)
+
+		comments, err := client.Comments(context.Background(), nzb, 1, 0)
+		require.NoError(t, err)
+		require.Empty(t, comments)
+
+		_, err = client.AddComment(context.Background(), nzb, "thanks")
+		require.EqualError(t, err, "newznab api error 203: Function not available")
+	})
+
+	t.Run("carts not supported", func(t *testing.T) {
+		require.Equal(t, newznab.ErrCartNotSupported, client.CartAdd(context.Background(), backend.nzbs[0]))
+		_, err := client.LoadCartFeed(context.Background(), false)
+		require.Equal(t, newznab.ErrCartNotSupported, err)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := newznab.New(ts.URL, "wrong", 1, false).SearchWithQuery(nil, "bones", "search")
+		require.EqualError(t, err, "newznab api error 100: Incorrect user credentials")
+
+		_, err = client.SearchWithQuery(nil, "bones", "nonsense")
+		require.EqualError(t, err, "newznab api error 202: No such function")
+
+		res, err := http.Get(ts.URL + "/api?t=details&id=missing&apikey=secret")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Contains(t, string(body), `<error code="300" description="No such item"></error>`)
+
+		res, err = http.Get(ts.URL + "/api?t=search&limit=abc&apikey=secret")
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err = ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Contains(t, string(body), `code="201"`)
+	})
+}
+
+// wrappingBackend wraps the errors of its details lookups like a backend built on the client
+type wrappingBackend struct {
+	testBackend
+}
+
+func (b *wrappingBackend) Details(ctx context.Context, id string) (newznab.NZB, error) {
+	nzb, err := b.testBackend.Details(ctx, id)
+	return nzb, errors.Wrap(err, "failed to load details")
+}
+
+func TestHandlerForwardedProto(t *testing.T) {
+	backend := &testBackend{nzbs: []newznab.NZB{{ID: "a", Title: "Bones.S10E22.DVDRip.X264-REWARD"}}}
+	search := func(t *testing.T, opts Options) string {
+		ts := httptest.NewServer(New(backend, opts))
+		defer ts.Close()
+		req, err := http.NewRequest(http.MethodGet, ts.URL+"/api?t=search", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Forwarded-Proto", "https")
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		return strings.Replace(string(body), strings.TrimPrefix(ts.URL, "http://"), "host", -1)
+	}
+
+	t.Run("ignored by default", func(t *testing.T) {
+		body := search(t, Options{})
+		require.Contains(t, body, "http://host/api?")
+		require.NotContains(t, body, "https://host")
+	})
+
+	t.Run("trusted", func(t *testing.T) {
+		body := search(t, Options{TrustForwardedProto: true})
+		require.Contains(t, body, "https://host/api?")
+		require.NotContains(t, body, "http://host")
+	})
+}
+
+func TestHandlerWrappedErrors(t *testing.T) {
+	ts := httptest.NewServer(New(&wrappingBackend{}, Options{}))
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL + "/api?t=details&id=missing")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `<error code="300" description="No such item"></error>`)
+}
+
+// catalogBackend returns its stored items without copying them
+type catalogBackend struct {
+	testBackend
+}
+
+func (b *catalogBackend) Search(ctx context.Context, q Query) (Results, error) {
+	return Results{NZBs: b.nzbs, Total: len(b.nzbs)}, nil
+}
+
+func TestHandlerSharedCatalog(t *testing.T) {
+	backend := &catalogBackend{}
+	backend.nzbs = []newznab.NZB{{ID: `a"b`, Title: "shared"}}
+	ts := httptest.NewServer(New(backend, Options{Authenticate: APIKeys("first", "second")}))
+	defer ts.Close()
+
+	for _, apikey := range []string{"first", "second"} {
+		results, err := newznab.New(ts.URL, apikey, 1, false).SearchWithQuery(nil, "shared", "search")
+		require.NoError(t, err)
+		require.Contains(t, results[0].DownloadURL, "apikey="+apikey)
+	}
+	require.Empty(t, backend.nzbs[0].DownloadURL, "the catalog of the backend is left alone")
+
+	res, err := http.Get(ts.URL + `/api?t=get&apikey=first&id=` + url.QueryEscape(`a"b`))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, `attachment; filename="a\"b.nzb"`, res.Header.Get("Content-Disposition"))
+}